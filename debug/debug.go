@@ -0,0 +1,199 @@
+// Package debug provides an http.Handler that reports the state of the
+// Regexps registered with the reonce package and of the Caches registered
+// with the recache package.
+//
+// The handler is not installed automatically, to serve it register it with
+// a ServeMux:
+//
+//	http.Handle("/debug/reonce", debug.Handler())
+//
+// The handler renders an HTML page by default and a JSON document if the
+// request has the query parameter "format=json" or only accepts
+// "application/json".
+package debug
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/charlievieth/reonce"
+	"github.com/charlievieth/reonce/recache"
+)
+
+// Regexp describes a Regexp registered with the reonce package.
+type Regexp struct {
+	Expr        string        `json:"expr"`
+	POSIX       bool          `json:"posix"`
+	Compiled    bool          `json:"compiled"`
+	Err         string        `json:"error,omitempty"`
+	CompileTime time.Duration `json:"compile_time"` // nanoseconds
+	File        string        `json:"file,omitempty"`
+	Line        int           `json:"line,omitempty"`
+}
+
+// Cache describes a Cache registered with the recache package.
+type Cache struct {
+	Name       string   `json:"name"`
+	POSIX      bool     `json:"posix"`
+	Len        int      `json:"len"`
+	MaxEntries int      `json:"max_entries"`
+	Hits       uint64   `json:"hits"`
+	Misses     uint64   `json:"misses"`
+	Evictions  uint64   `json:"evictions"`
+	Entries    []string `json:"entries"` // most recently used first
+}
+
+// A Snapshot is the state of the registered Regexps and Caches, it is the
+// JSON document served by Handler.
+type Snapshot struct {
+	Regexps []Regexp `json:"regexps"`
+	Caches  []Cache  `json:"caches"`
+}
+
+// Take returns a Snapshot of the registered Regexps and Caches. It does not
+// compile any Regexps.
+func Take() *Snapshot {
+	s := &Snapshot{
+		Regexps: []Regexp{},
+		Caches:  []Cache{},
+	}
+	reonce.Walk(func(re *reonce.Regexp) bool {
+		st := re.Stats()
+		r := Regexp{
+			Expr:        st.Expr,
+			POSIX:       st.POSIX,
+			Compiled:    st.Compiled,
+			CompileTime: st.CompileTime,
+			File:        st.File,
+			Line:        st.Line,
+		}
+		if st.Err != nil {
+			r.Err = st.Err.Error()
+		}
+		s.Regexps = append(s.Regexps, r)
+		return true
+	})
+	recache.Walk(func(name string, c *recache.Cache) bool {
+		st := c.Stats()
+		entries := c.Keys()
+		if entries == nil {
+			entries = []string{}
+		}
+		s.Caches = append(s.Caches, Cache{
+			Name:       name,
+			POSIX:      c.POSIX(),
+			Len:        st.Len,
+			MaxEntries: st.MaxEntries,
+			Hits:       st.Hits,
+			Misses:     st.Misses,
+			Evictions:  st.Evictions,
+			Entries:    entries,
+		})
+		return true
+	})
+	return s
+}
+
+// Handler returns an http.Handler that serves a Snapshot of the registered
+// Regexps and Caches as HTML or JSON.
+func Handler() http.Handler {
+	return http.HandlerFunc(serve)
+}
+
+func serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	s := Take()
+	w.Header().Set("Cache-Control", "no-store")
+	if wantJSON(r) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		enc.Encode(s)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, s); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// wantJSON reports if the JSON view was requested, either with the query
+// parameter "format=json" or with an Accept header that only lists JSON.
+func wantJSON(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "json":
+		return true
+	case "html":
+		return false
+	}
+	accept := r.Header.Get("Accept")
+	return accept != "" && !strings.Contains(accept, "html") &&
+		strings.Contains(accept, "application/json")
+}
+
+var page = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>reonce</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 6px; text-align: left; vertical-align: top; }
+td.num { text-align: right; }
+code { white-space: pre-wrap; word-break: break-all; }
+.err { color: #b00; }
+</style>
+</head>
+<body>
+<h1>reonce</h1>
+<p><a href="?format=json">JSON</a></p>
+
+<h2>Regexps ({{len .Regexps}})</h2>
+<table>
+<tr><th>Pattern</th><th>POSIX</th><th>Compiled</th><th>Compile time</th><th>Declared at</th><th>Error</th></tr>
+{{- range .Regexps}}
+<tr>
+<td><code>{{.Expr}}</code></td>
+<td>{{.POSIX}}</td>
+<td>{{.Compiled}}</td>
+<td class="num">{{if .Compiled}}{{.CompileTime}}{{end}}</td>
+<td>{{if .File}}{{.File}}:{{.Line}}{{end}}</td>
+<td class="err">{{.Err}}</td>
+</tr>
+{{- end}}
+</table>
+
+<h2>Caches ({{len .Caches}})</h2>
+{{- range .Caches}}
+<h3>{{.Name}}</h3>
+<table>
+<tr><th>POSIX</th><th>Size</th><th>MaxEntries</th><th>Hits</th><th>Misses</th><th>Evictions</th></tr>
+<tr>
+<td>{{.POSIX}}</td>
+<td class="num">{{.Len}}</td>
+<td class="num">{{if .MaxEntries}}{{.MaxEntries}}{{else}}unlimited{{end}}</td>
+<td class="num">{{.Hits}}</td>
+<td class="num">{{.Misses}}</td>
+<td class="num">{{.Evictions}}</td>
+</tr>
+</table>
+{{- if .Entries}}
+<table>
+<tr><th>LRU position</th><th>Pattern</th></tr>
+{{- range $i, $e := .Entries}}
+<tr><td class="num">{{$i}}</td><td><code>{{$e}}</code></td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,180 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/charlievieth/reonce"
+	"github.com/charlievieth/reonce/recache"
+)
+
+var (
+	goodRe = reonce.New(`debug[0-9]+`)
+	badRe  = reonce.NewPOSIX(`debug(`)
+	lazyRe = reonce.New(`debug<lazy>`)
+)
+
+var testCache = recache.New(2)
+
+func init() {
+	recache.Register("debug-test", testCache)
+}
+
+var setupOnce sync.Once
+
+func setup(t *testing.T) {
+	setupOnce.Do(func() {
+		goodRe.Compile()
+		badRe.Compile()
+		for _, s := range []string{"a", "b", "c", "b"} {
+			testCache.MustCompile(s)
+		}
+	})
+}
+
+func get(t *testing.T, url string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("GET", url, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d: %s", url, w.Code, w.Body)
+	}
+	return w
+}
+
+func findRegexp(t *testing.T, s *Snapshot, expr string) Regexp {
+	t.Helper()
+	for _, r := range s.Regexps {
+		if r.Expr == expr {
+			return r
+		}
+	}
+	t.Fatalf("Regexp %q not found in snapshot", expr)
+	return Regexp{}
+}
+
+func findCache(t *testing.T, s *Snapshot, name string) Cache {
+	t.Helper()
+	for _, c := range s.Caches {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("Cache %q not found in snapshot", name)
+	return Cache{}
+}
+
+func testSnapshot(t *testing.T, s *Snapshot) {
+	good := findRegexp(t, s, goodRe.String())
+	if !good.Compiled || good.Err != "" || good.POSIX {
+		t.Errorf("good: %+v", good)
+	}
+	if !strings.HasSuffix(good.File, "debug_test.go") || good.Line == 0 {
+		t.Errorf("good: declaration site: %s:%d", good.File, good.Line)
+	}
+
+	bad := findRegexp(t, s, badRe.String())
+	if !bad.Compiled || bad.Err == "" || !bad.POSIX {
+		t.Errorf("bad: %+v", bad)
+	}
+
+	lazy := findRegexp(t, s, lazyRe.String())
+	if lazy.Compiled || lazy.CompileTime != 0 {
+		t.Errorf("lazy: %+v", lazy)
+	}
+	if lazyRe.Compiled() {
+		t.Error("taking a snapshot should not compile Regexps")
+	}
+
+	c := findCache(t, s, "debug-test")
+	want := Cache{
+		Name:       "debug-test",
+		Len:        2,
+		MaxEntries: 2,
+		Hits:       1,
+		Misses:     3,
+		Evictions:  1,
+		Entries:    []string{"b", "c"},
+	}
+	if c.Name != want.Name || c.Len != want.Len || c.MaxEntries != want.MaxEntries ||
+		c.Hits != want.Hits || c.Misses != want.Misses || c.Evictions != want.Evictions ||
+		strings.Join(c.Entries, ",") != strings.Join(want.Entries, ",") {
+		t.Errorf("Cache = %+v; want: %+v", c, want)
+	}
+	findCache(t, s, "std")
+	findCache(t, s, "posix")
+}
+
+func TestTake(t *testing.T) {
+	setup(t)
+	testSnapshot(t, Take())
+}
+
+func TestHandlerJSON(t *testing.T) {
+	setup(t)
+	tests := []struct {
+		url    string
+		header http.Header
+	}{
+		{"/debug/reonce?format=json", nil},
+		{"/debug/reonce", http.Header{"Accept": {"application/json"}}},
+	}
+	for _, test := range tests {
+		w := get(t, test.url, test.header)
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("%s: Content-Type = %q", test.url, ct)
+		}
+		var s Snapshot
+		if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+			t.Fatalf("%s: %v", test.url, err)
+		}
+		testSnapshot(t, &s)
+	}
+}
+
+func TestHandlerHTML(t *testing.T) {
+	setup(t)
+	for _, accept := range []string{"", "text/html,application/json"} {
+		w := get(t, "/debug/reonce", http.Header{"Accept": {accept}})
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body := w.Body.String()
+		for _, s := range []string{
+			"<code>debug[0-9]&#43;</code>",
+			"<code>debug&lt;lazy&gt;</code>",
+			"debug_test.go:",
+			"error parsing regexp",
+			"<h3>debug-test</h3>",
+			"<h3>std</h3>",
+			"<h3>posix</h3>",
+		} {
+			if !strings.Contains(body, s) {
+				t.Errorf("HTML view does not contain %q:\n%s", s, body)
+			}
+		}
+		if i, j := strings.Index(body, "<code>b</code>"), strings.Index(body, "<code>c</code>"); i < 0 || j < 0 || i > j {
+			t.Errorf("cache entries are not in LRU order:\n%s", body)
+		}
+	}
+}
+
+func TestHandlerMethod(t *testing.T) {
+	req := httptest.NewRequest("POST", "/debug/reonce", nil)
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d; want: %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
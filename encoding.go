@@ -0,0 +1,65 @@
+package reonce
+
+// AppendText implements encoding.TextAppender. It appends the source text
+// of the Regexp to b. It does not compile the Regexp.
+func (re *Regexp) AppendText(b []byte) ([]byte, error) {
+	return append(b, re.expr...), nil
+}
+
+// MarshalText implements encoding.TextMarshaler. The output matches that of
+// calling the String method. It does not compile the Regexp.
+func (re *Regexp) MarshalText() ([]byte, error) {
+	return []byte(re.expr), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler by storing the pattern
+// text, which is lazily compiled on first use. The options of the Regexp,
+// such as POSIX, are preserved. Use StrictRegexp to report invalid patterns
+// when decoding.
+//
+// UnmarshalText resets the Regexp and may not be called concurrently with
+// any other methods. A registered Regexp (see Walk) is removed from the
+// registry and from the background compile queue (see ModeBackground), so
+// that they do not compile it while it is reset, but it must not be decoded
+// while Warm or ValidateAllParallel are running.
+func (re *Regexp) UnmarshalText(text []byte) error {
+	re.reset(string(text))
+	return nil
+}
+
+// reset replaces the pattern of re with expr and marks it uncompiled. It
+// first removes re from the registry and the background compile queue,
+// waiting for it if it is being compiled in the background, since
+// resetting re while another goroutine compiles it is a data race.
+func (re *Regexp) reset(expr string) {
+	unregister(re)
+	cancelBackground(re)
+	*re = Regexp{expr: expr, opts: re.opts, pc: re.pc}
+}
+
+// StrictRegexp is a Regexp that is compiled when it is decoded, so that
+// invalid patterns are reported as decoding errors instead of when the
+// Regexp is first used. The zero value is a Regexp that matches the empty
+// pattern.
+//
+// Like Regexp, a StrictRegexp must not be copied and its methods have
+// pointer receivers, so use a *StrictRegexp in structs that are decoded.
+// Otherwise, copying the struct is reported by go vet and encoding it by
+// value does not use MarshalText.
+//
+//	type Config struct {
+//		Include *reonce.StrictRegexp `json:"include"`
+//	}
+type StrictRegexp struct {
+	Regexp
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Unlike
+// Regexp.UnmarshalText the pattern is compiled immediately and the
+// *CompileError, if any, is returned.
+func (re *StrictRegexp) UnmarshalText(text []byte) error {
+	if err := re.Regexp.UnmarshalText(text); err != nil {
+		return err
+	}
+	return re.Compile()
+}
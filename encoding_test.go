@@ -0,0 +1,150 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+var (
+	_ encoding.TextMarshaler   = (*Regexp)(nil)
+	_ encoding.TextUnmarshaler = (*Regexp)(nil)
+	_ encoding.TextUnmarshaler = (*StrictRegexp)(nil)
+)
+
+func TestMarshalText(t *testing.T) {
+	re := New("*")
+	b, err := re.MarshalText()
+	if err != nil || string(b) != "*" {
+		t.Errorf("MarshalText() = %q, %v want: %q, nil", b, err, "*")
+	}
+	b, err = re.AppendText([]byte("x"))
+	if err != nil || string(b) != "x*" {
+		t.Errorf("AppendText() = %q, %v want: %q, nil", b, err, "x*")
+	}
+	if re.Compiled() {
+		t.Error("MarshalText should not compile the Regexp")
+	}
+}
+
+func TestUnmarshalText(t *testing.T) {
+	re := NewPOSIX("a")
+	re.MustCompile()
+	if err := re.UnmarshalText([]byte("a|ab")); err != nil {
+		t.Fatal(err)
+	}
+	if re.Compiled() {
+		t.Error("UnmarshalText should reset the Regexp")
+	}
+	if re.String() != "a|ab" {
+		t.Errorf("String() = %q want: %q", re.String(), "a|ab")
+	}
+	// options are preserved
+	if got := re.FindString("ab"); got != "ab" {
+		t.Errorf("FindString: got: %q want: %q", got, "ab")
+	}
+
+	// invalid patterns are not reported until used
+	if err := re.UnmarshalText([]byte("[a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := re.Compile(); err == nil {
+		t.Error("expected a compile error")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type config struct {
+		Ptr    *Regexp
+		Val    Regexp
+		Strict StrictRegexp
+		Opt    *Regexp `json:",omitempty"`
+	}
+	const data = `{"Ptr":"a+","Val":"\\d+","Strict":"[[:alpha:]]+"}`
+
+	var c config
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Ptr.Compiled() || c.Val.Compiled() {
+		t.Error("decoded Regexps should be compiled lazily")
+	}
+	if !c.Strict.Compiled() {
+		t.Error("StrictRegexp should be compiled when decoded")
+	}
+	if !c.Ptr.MatchString("aa") || !c.Val.MatchString("12") || !c.Strict.MatchString("ab") {
+		t.Error("decoded Regexps failed to match")
+	}
+	b, err := json.Marshal(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != data {
+		t.Errorf("Marshal: got: %s want: %s", b, data)
+	}
+
+	// invalid patterns
+	if err := json.Unmarshal([]byte(`{"Ptr":"(a"}`), &c); err != nil {
+		t.Errorf("Regexp should not be validated when decoded: %v", err)
+	}
+	err = json.Unmarshal([]byte(`{"Strict":"(a"}`), &c)
+	var cerr *CompileError
+	if !errors.As(err, &cerr) || cerr.Expr != "(a" {
+		t.Errorf("StrictRegexp: expected a *CompileError got: %v", err)
+	}
+}
+
+// Test the documented use of StrictRegexp, which is as a pointer so that
+// the struct can be copied and encoded by value.
+func TestJSONStrictPointer(t *testing.T) {
+	type config struct {
+		Include *StrictRegexp `json:"include"`
+		Exclude *StrictRegexp `json:"exclude,omitempty"`
+	}
+	const data = `{"include":"a+"}`
+
+	var c config
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Include.Compiled() || !c.Include.MatchString("aa") {
+		t.Error("StrictRegexp should be compiled when decoded")
+	}
+	copied := c
+	b, err := json.Marshal(copied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != data {
+		t.Errorf("Marshal: got: %s want: %s", b, data)
+	}
+
+	var cerr *CompileError
+	err = json.Unmarshal([]byte(`{"exclude":"(a"}`), &c)
+	if !errors.As(err, &cerr) || cerr.Expr != "(a" {
+		t.Errorf("StrictRegexp: expected a *CompileError got: %v", err)
+	}
+}
+
+// Decoding into a registered Regexp must not race with the background
+// compilation of the Regexp, run with -race.
+func TestUnmarshalTextBackground(t *testing.T) {
+	withRegistry(t)
+	setMode(t, ModeBackground)
+	for i := 0; i < 100; i++ {
+		re := NewWithOptions(`default\d+`, Options{POSIX: true})
+		if err := json.Unmarshal([]byte(`"x+"`), re); err != nil {
+			t.Fatal(err)
+		}
+		if isRegistered(re) {
+			t.Fatal("decoded Regexp should be removed from the registry")
+		}
+		if !re.POSIX() || re.String() != "x+" || !re.MatchString("xx") {
+			t.Fatalf("decoded Regexp: %q POSIX: %t", re, re.POSIX())
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package reonce
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// registrySummary is the value of the "reonce" expvar variable.
+type registrySummary struct {
+	Declared    int           // number of registered Regexps
+	Compiled    int           // number of registered Regexps that have been compiled
+	Failed      int           // number of registered Regexps that failed to compile
+	CompileTime time.Duration // total time spent compiling, in nanoseconds
+}
+
+// summarize returns a summary of the state of the registry.
+func summarize() registrySummary {
+	list := registered()
+	s := registrySummary{Declared: len(list)}
+	for _, re := range list {
+		if !re.Compiled() {
+			continue
+		}
+		s.Compiled++
+		if re.err != nil {
+			s.Failed++
+		}
+		s.CompileTime += re.compileTime
+	}
+	return s
+}
+
+var publishOnce sync.Once
+
+// Publish publishes a summary of the registered Regexps (see Walk) as the
+// expvar variable "reonce", which is a JSON object with the number of
+// Regexps that were declared, compiled and failed to compile and the total
+// time spent compiling them in nanoseconds:
+//
+//	{"Declared": 12, "Compiled": 4, "Failed": 0, "CompileTime": 183042}
+//
+// The summary is computed when the variable is read. Importing the package
+// does not publish anything and it is safe to call Publish more than once.
+// Use recache.Publish to publish the statistics of the default caches.
+func Publish() {
+	publishOnce.Do(func() {
+		expvar.Publish("reonce", expvar.Func(func() any {
+			return summarize()
+		}))
+	})
+}
@@ -0,0 +1,59 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+)
+
+// published is set once TestPublish has called Publish, expvar variables
+// cannot be removed so they remain published when tests are repeated.
+var published bool
+
+func TestPublish(t *testing.T) {
+	if v := expvar.Get("reonce"); v != nil && !published {
+		t.Fatalf("reonce published before calling Publish: %s", v)
+	}
+	published = true
+	good := New(`a+`)
+	bad := New(`a(`)
+	lazy := New(`b+`)
+	withRegistry(t, good, bad, lazy)
+	good.Compile()
+	bad.Compile()
+
+	Publish()
+	Publish() // no-op
+
+	var got registrySummary
+	if err := json.Unmarshal([]byte(expvar.Get("reonce").String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := registrySummary{
+		Declared:    3,
+		Compiled:    2,
+		Failed:      1,
+		CompileTime: good.compileTime + bad.compileTime,
+	}
+	if got != want {
+		t.Errorf("reonce = %+v; want: %+v", got, want)
+	}
+
+	lazy.Compile()
+	if got := summarize(); got.Compiled != 3 || got.CompileTime < want.CompileTime {
+		t.Errorf("summarize() = %+v; want 3 compiled", got)
+	}
+}
+
+func TestPublishCompileTime(t *testing.T) {
+	re := New(`(a|b)*c{5,10}`)
+	withRegistry(t, re)
+	re.Compile()
+	if got := summarize().CompileTime; got != re.compileTime || got > time.Minute {
+		t.Errorf("CompileTime = %s; want: %s", got, re.compileTime)
+	}
+}
@@ -0,0 +1,128 @@
+package reonce
+
+import (
+	"flag"
+	"strings"
+)
+
+// Set implements flag.Value. It replaces the pattern of the Regexp with s,
+// preserving its options, and compiles it so that an invalid pattern is
+// reported as a flag parsing error. The returned error, if any, is a
+// *CompileError. Set may not be called concurrently with any other methods.
+func (re *Regexp) Set(s string) error {
+	re.reset(s)
+	return re.Compile()
+}
+
+// Flag defines a Regexp flag with the specified name, default pattern, and
+// usage string. The return value is the address of a Regexp that stores the
+// value of the flag. The default pattern is compiled lazily, like New, but
+// patterns passed on the command line are compiled when parsed. Unlike New,
+// the Regexp is not registered (see Walk) since its pattern is replaced
+// when the flag is parsed.
+//
+// To define a Regexp flag on a flag.FlagSet use FlagSet.Var since *Regexp
+// implements flag.Value.
+func Flag(name, value, usage string) *Regexp {
+	re := newFlagRegexp(value, Options{}, callerPC())
+	flag.Var(re, name, usage)
+	return re
+}
+
+// FlagPOSIX is like Flag but the patterns use POSIX syntax, see NewPOSIX.
+func FlagPOSIX(name, value, usage string) *Regexp {
+	re := newFlagRegexp(value, Options{POSIX: true}, callerPC())
+	flag.Var(re, name, usage)
+	return re
+}
+
+// newFlagRegexp is like newRegexp but the Regexp is not registered, since
+// its pattern is replaced in place when the flag is parsed, which must not
+// race with Warm, ValidateAllParallel or SetMode compiling it, and it is
+// never compiled in the background for the same reason.
+func newFlagRegexp(value string, opts Options, pc uintptr) *Regexp {
+	re := &Regexp{expr: value, opts: opts, pc: pc}
+	if m := GetMode(); m != ModeBackground {
+		re.applyMode(m)
+	}
+	return re
+}
+
+// FlagVar defines a Regexp flag with the specified name, default pattern,
+// and usage string. The argument re points to a Regexp that stores the value
+// of the flag. The options of re, such as POSIX, are used to compile the
+// patterns passed on the command line. If re was created by New or one of
+// its variants it is removed from the registry, like the Regexps of Flag
+// and RegexpList, see UnmarshalText.
+func FlagVar(re *Regexp, name, value, usage string) {
+	re.reset(value)
+	flag.Var(re, name, usage)
+}
+
+// RegexpList is a flag.Value that collects the patterns of a repeated flag,
+// such as -include, into a list of Regexps. Patterns are compiled when they
+// are parsed so that invalid patterns are reported as flag parsing errors.
+// Like the Regexps of Flag, they are not registered (see Walk). The zero
+// value is an empty list that uses the default Options.
+type RegexpList struct {
+	// Options used to compile the patterns.
+	Options Options
+
+	// Regexps contains a Regexp for each occurrence of the flag in the
+	// order they were parsed.
+	Regexps []*Regexp
+}
+
+// FlagList defines a repeatable Regexp flag with the specified name and
+// usage string. The return value is the address of a RegexpList that stores
+// the values of the flag.
+func FlagList(name, usage string) *RegexpList {
+	l := new(RegexpList)
+	FlagListVar(l, name, usage)
+	return l
+}
+
+// FlagListVar defines a repeatable Regexp flag with the specified name and
+// usage string. The argument l points to a RegexpList that stores the values
+// of the flag, its Options are used to compile the patterns.
+func FlagListVar(l *RegexpList, name, usage string) {
+	flag.Var(l, name, usage)
+}
+
+// Set implements flag.Value by compiling s and appending it to the list.
+// The returned error, if any, is a *CompileError.
+func (l *RegexpList) Set(s string) error {
+	re := &Regexp{expr: s, opts: l.Options}
+	if err := re.Compile(); err != nil {
+		return err
+	}
+	l.Regexps = append(l.Regexps, re)
+	return nil
+}
+
+// String implements flag.Value and returns the patterns of the list
+// separated by commas.
+func (l *RegexpList) String() string {
+	if l == nil {
+		return ""
+	}
+	exprs := make([]string, len(l.Regexps))
+	for i, re := range l.Regexps {
+		exprs[i] = re.String()
+	}
+	return strings.Join(exprs, ",")
+}
+
+// Len returns the number of Regexps in the list.
+func (l *RegexpList) Len() int { return len(l.Regexps) }
+
+// MatchString reports whether s contains any match of any of the Regexps in
+// the list.
+func (l *RegexpList) MatchString(s string) bool {
+	for _, re := range l.Regexps {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
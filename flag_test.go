@@ -0,0 +1,111 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+var (
+	_ flag.Value = (*Regexp)(nil)
+	_ flag.Value = (*RegexpList)(nil)
+)
+
+// withCommandLine replaces flag.CommandLine for the duration of the test.
+func withCommandLine(t *testing.T) *flag.FlagSet {
+	saved := flag.CommandLine
+	flag.CommandLine = flag.NewFlagSet("test", flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+	t.Cleanup(func() { flag.CommandLine = saved })
+	return flag.CommandLine
+}
+
+func TestFlag(t *testing.T) {
+	fs := withCommandLine(t)
+	re := Flag("re", "a+", "usage")
+	posix := FlagPOSIX("posix", "", "usage")
+	v := New("")
+	FlagVar(v, "var", `\d`, "usage")
+
+	if re.String() != "a+" || v.String() != `\d` {
+		t.Errorf("default patterns: got: %q, %q", re.String(), v.String())
+	}
+	if isRegistered(re) || isRegistered(posix) || isRegistered(v) {
+		t.Error("flag Regexps should not be registered")
+	}
+	if file, _, ok := re.Caller(); !ok || !strings.HasSuffix(file, "flag_test.go") {
+		t.Errorf("Flag should record the declaration site: %q", file)
+	}
+	if err := fs.Parse([]string{"-re", "b+", "-posix", "a|ab", "-var", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if !re.Compiled() || !re.MatchString("bb") || re.MatchString("aa") {
+		t.Errorf("-re: got: %q", re.String())
+	}
+	if !posix.POSIX() || posix.FindString("ab") != "ab" {
+		t.Errorf("-posix: expected leftmost-longest match got: %q", posix.FindString("ab"))
+	}
+	if !v.MatchString("c") {
+		t.Errorf("-var: got: %q", v.String())
+	}
+}
+
+func TestFlagInvalid(t *testing.T) {
+	fs := withCommandLine(t)
+	Flag("re", "", "usage")
+	err := fs.Parse([]string{"-re", "(a"})
+	if err == nil || !strings.Contains(err.Error(), "missing closing )") {
+		t.Errorf("expected a parse error got: %v", err)
+	}
+}
+
+func TestFlagList(t *testing.T) {
+	fs := withCommandLine(t)
+	include := FlagList("include", "usage")
+	exclude := &RegexpList{Options: Options{POSIX: true}}
+	FlagListVar(exclude, "exclude", "usage")
+
+	err := fs.Parse([]string{"-include", `\.go$`, "-exclude", "_test", "-include", `\.s$`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if include.Len() != 2 || include.String() != `\.go$,\.s$` {
+		t.Errorf("include: got: %d %q", include.Len(), include.String())
+	}
+	if exclude.Len() != 1 || !exclude.Regexps[0].POSIX() {
+		t.Errorf("exclude: got: %d %q", exclude.Len(), exclude.String())
+	}
+	for _, re := range append(include.Regexps, exclude.Regexps...) {
+		if isRegistered(re) {
+			t.Errorf("RegexpList: %q should not be registered", re)
+		}
+	}
+	for _, test := range []struct {
+		s    string
+		want bool
+	}{
+		{"main.go", true},
+		{"asm.s", true},
+		{"main.c", false},
+	} {
+		if got := include.MatchString(test.s); got != test.want {
+			t.Errorf("MatchString(%q) = %t want: %t", test.s, got, test.want)
+		}
+	}
+
+	var cerr *CompileError
+	if err := include.Set("[a"); !errors.As(err, &cerr) {
+		t.Errorf("Set: expected a *CompileError got: %v", err)
+	}
+	if include.Len() != 2 {
+		t.Error("invalid patterns should not be added to the list")
+	}
+	if s := (*RegexpList)(nil).String(); s != "" {
+		t.Errorf("nil String() = %q", s)
+	}
+}
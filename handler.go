@@ -0,0 +1,96 @@
+package reonce
+
+import (
+	"log/slog"
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// An ErrorHandler is called with the compile error of a Regexp that failed
+// to compile each time it is used by a method that cannot return an error.
+// If the handler returns, instead of panicking, the Regexp behaves as one
+// that never matches.
+//
+// The ErrorHandler used by a Regexp is Options.OnError, if set, otherwise
+// the package level handler set by SetErrorHandler. The default handler is
+// PanicOnError. Methods that return an error, such as Compile and the
+// methods of TryRegexp, do not use the ErrorHandler.
+type ErrorHandler func(err *CompileError)
+
+// PanicOnError is the default ErrorHandler and panics with err. This
+// matches the behavior of regexp.MustCompile.
+func PanicOnError(err *CompileError) { panic(err) }
+
+// NeverMatch returns an ErrorHandler that treats Regexps that failed to
+// compile as never matching. If report is not nil it is called once for
+// each Regexp that failed to compile, the first time it is used.
+func NeverMatch(report func(err *CompileError)) ErrorHandler {
+	if report == nil {
+		return func(*CompileError) {}
+	}
+	var seen sync.Map // *CompileError => struct{}
+	return func(err *CompileError) {
+		if _, loaded := seen.LoadOrStore(err, struct{}{}); !loaded {
+			report(err)
+		}
+	}
+}
+
+// LogError returns an ErrorHandler that treats Regexps that failed to
+// compile as never matching and logs the error of each one to logger once.
+// If logger is nil, slog.Default() is used.
+func LogError(logger *slog.Logger) ErrorHandler {
+	return NeverMatch(func(err *CompileError) {
+		l := logger
+		if l == nil {
+			l = slog.Default()
+		}
+		attrs := []any{
+			slog.String("expr", err.Expr),
+			slog.Bool("posix", err.POSIX),
+			slog.Any("error", err.Err),
+		}
+		if err.File != "" {
+			attrs = append(attrs, slog.String("file", err.File), slog.Int("line", err.Line))
+		}
+		l.Error("reonce: invalid regexp", attrs...)
+	})
+}
+
+var errorHandler atomic.Pointer[ErrorHandler]
+
+// SetErrorHandler sets the package level ErrorHandler used by Regexps that
+// do not set Options.OnError and returns the previous handler. If h is nil
+// the default handler, PanicOnError, is used.
+func SetErrorHandler(h ErrorHandler) (prev ErrorHandler) {
+	var p *ErrorHandler
+	if h != nil {
+		p = &h
+	}
+	if p = errorHandler.Swap(p); p != nil {
+		return *p
+	}
+	return PanicOnError
+}
+
+// neverMatch is the Regexp used in place of Regexps that failed to compile
+// when the ErrorHandler does not panic.
+var neverMatch = sync.OnceValue(func() *regexp.Regexp {
+	return regexp.MustCompile(`[^\x00-\x{10FFFF}]`)
+})
+
+// handleError calls the ErrorHandler of re with its compile error and,
+// if the handler returns, a Regexp that never matches.
+func (re *Regexp) handleError() *regexp.Regexp {
+	h := re.opts.OnError
+	if h == nil {
+		if p := errorHandler.Load(); p != nil {
+			h = *p
+		} else {
+			h = PanicOnError
+		}
+	}
+	h(re.err.(*CompileError))
+	return neverMatch()
+}
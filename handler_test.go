@@ -0,0 +1,124 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestErrorHandlerNeverMatch(t *testing.T) {
+	var reports []*CompileError
+	re := NewWithOptions("*", Options{
+		OnError: NeverMatch(func(err *CompileError) {
+			reports = append(reports, err)
+		}),
+	})
+
+	typ := reflect.TypeOf(re)
+	for i := 0; i < typ.NumMethod(); i++ {
+		m := typ.Method(i)
+		if m.Name == "MustCompile" || m.Name == "Scan" || m.Name == "Set" || m.Name == "UnmarshalText" {
+			continue
+		}
+		t.Run(m.Name, func(t *testing.T) {
+			defer func() {
+				if e := recover(); e != nil {
+					t.Fatalf("panic: %v", e)
+				}
+			}()
+			meth := reflect.ValueOf(re).MethodByName(m.Name)
+			meth.Call(buildMethodArgs(t, meth))
+		})
+	}
+
+	if re.MatchString("") || re.FindStringIndex("*") != nil || re.FindAllString("a*", -1) != nil {
+		t.Error("Regexp should never match")
+	}
+	if got := re.ReplaceAllString("abc", "x"); got != "abc" {
+		t.Errorf("ReplaceAllString: got: %q want: %q", got, "abc")
+	}
+	if len(reports) != 1 || reports[0] != re.Err() {
+		t.Errorf("report should be called once with the error: %v", reports)
+	}
+
+	// MustCompile always panics
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompile: expected a panic")
+		}
+	}()
+	re.MustCompile()
+}
+
+func TestErrorHandlerCustom(t *testing.T) {
+	var calls int
+	re := NewWithOptions("[a", Options{
+		OnError: func(err *CompileError) { calls++ },
+	})
+	for i := 0; i < 3; i++ {
+		if re.MatchString("[a") {
+			t.Error("Regexp should never match")
+		}
+	}
+	if calls != 3 {
+		t.Errorf("handler should be called on every use: got: %d want: %d", calls, 3)
+	}
+}
+
+func TestLogError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	re := NewWithOptions("(a", Options{OnError: LogError(logger)})
+	re.MatchString("a")
+	re.MatchString("a")
+
+	out := buf.String()
+	if strings.Count(out, "\n") != 1 {
+		t.Errorf("expected the error to be logged once got: %q", out)
+	}
+	for _, s := range []string{"reonce: invalid regexp", "expr=(a", "handler_test.go"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("expected log to contain %q got: %q", s, out)
+		}
+	}
+}
+
+func TestSetErrorHandler(t *testing.T) {
+	var calls int
+	prev := SetErrorHandler(func(*CompileError) { calls++ })
+	defer SetErrorHandler(prev)
+	if reflect.ValueOf(prev).Pointer() != reflect.ValueOf(PanicOnError).Pointer() {
+		t.Error("the default ErrorHandler should be PanicOnError")
+	}
+
+	if New("*").MatchString("a") {
+		t.Error("Regexp should never match")
+	}
+	if calls != 1 {
+		t.Errorf("package ErrorHandler not called: %d", calls)
+	}
+
+	// Options.OnError takes precedence
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected Options.OnError to panic")
+			}
+		}()
+		NewWithOptions("*", Options{OnError: PanicOnError}).MatchString("a")
+	}()
+
+	// reset to the default
+	SetErrorHandler(nil)
+	defer func() {
+		if _, ok := recover().(*CompileError); !ok {
+			t.Error("expected the default ErrorHandler to panic")
+		}
+	}()
+	New("*").MatchString("a")
+}
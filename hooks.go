@@ -0,0 +1,129 @@
+package reonce
+
+import (
+	"context"
+	"log/slog"
+	"runtime/trace"
+	"sync/atomic"
+	"time"
+)
+
+// A CompileEvent describes the compilation of a Regexp to Hooks.
+type CompileEvent struct {
+	Expr     string        // the pattern
+	POSIX    bool          // the pattern uses POSIX syntax
+	File     string        // file where the Regexp was declared, empty if unknown
+	Line     int           // line where the Regexp was declared
+	Duration time.Duration // time taken to compile, zero for OnFirstUse
+	Err      error         // the *CompileError, set for OnError
+}
+
+// Hooks are functions called when a Regexp is compiled, which allows
+// compilation to be traced and logged. Any of the functions may be nil.
+// They are called by the goroutine that compiles the Regexp, while other
+// users of the Regexp wait for it to be compiled, and must not use the
+// Regexp.
+//
+// The Hooks used by a Regexp are Options.Hooks, if set, otherwise the
+// package level Hooks set by SetHooks. See LogHooks and TraceHooks for
+// ready-made Hooks.
+type Hooks struct {
+	// OnFirstUse is called when the Regexp is first used, before it is
+	// compiled. If it returns a function, that function is called once
+	// compilation completes, before OnCompile or OnError.
+	OnFirstUse func(ev *CompileEvent) (done func())
+
+	// OnCompile is called after the Regexp compiled successfully.
+	OnCompile func(ev *CompileEvent)
+
+	// OnError is called after the Regexp failed to compile.
+	OnError func(ev *CompileEvent)
+}
+
+var hooks atomic.Pointer[Hooks]
+
+// SetHooks sets the package level Hooks used by Regexps that do not set
+// Options.Hooks and returns the previous Hooks. Only Regexps compiled after
+// the call use h. If h is nil no Hooks are called.
+func SetHooks(h *Hooks) (prev *Hooks) {
+	return hooks.Swap(h)
+}
+
+// hooks returns the Hooks of re or nil if there are none.
+func (re *Regexp) hooks() *Hooks {
+	if re.opts.Hooks != nil {
+		return re.opts.Hooks
+	}
+	return hooks.Load()
+}
+
+// compileEvent returns a CompileEvent for re without the compile result.
+func (re *Regexp) compileEvent() *CompileEvent {
+	file, line, _ := re.Caller()
+	return &CompileEvent{
+		Expr:  re.expr,
+		POSIX: re.opts.POSIX,
+		File:  file,
+		Line:  line,
+	}
+}
+
+// LogHooks returns Hooks that log compile errors to logger at level Error
+// and successful compiles at level Debug. If logger is nil, slog.Default()
+// is used.
+func LogHooks(logger *slog.Logger) *Hooks {
+	log := func(level slog.Level, msg string, ev *CompileEvent) {
+		l := logger
+		if l == nil {
+			l = slog.Default()
+		}
+		ctx := context.Background()
+		if !l.Enabled(ctx, level) {
+			return
+		}
+		attrs := []slog.Attr{
+			slog.String("expr", ev.Expr),
+			slog.Bool("posix", ev.POSIX),
+			slog.Duration("duration", ev.Duration),
+		}
+		if ev.File != "" {
+			attrs = append(attrs, slog.String("file", ev.File), slog.Int("line", ev.Line))
+		}
+		if ev.Err != nil {
+			attrs = append(attrs, slog.Any("error", ev.Err))
+		}
+		l.LogAttrs(ctx, level, msg, attrs...)
+	}
+	return &Hooks{
+		OnCompile: func(ev *CompileEvent) {
+			log(slog.LevelDebug, "reonce: compiled regexp", ev)
+		},
+		OnError: func(ev *CompileEvent) {
+			log(slog.LevelError, "reonce: invalid regexp", ev)
+		},
+	}
+}
+
+// TraceHooks returns Hooks that record the compilation of each Regexp as a
+// runtime/trace region named "reonce.Compile", which contains a log message
+// with the category "reonce.expr" and the pattern. Compile errors are
+// logged with the category "reonce.error". The Hooks do nothing while
+// tracing is not enabled.
+func TraceHooks() *Hooks {
+	return &Hooks{
+		OnFirstUse: func(ev *CompileEvent) func() {
+			if !trace.IsEnabled() {
+				return nil
+			}
+			ctx := context.Background()
+			region := trace.StartRegion(ctx, "reonce.Compile")
+			trace.Log(ctx, "reonce.expr", ev.Expr)
+			return region.End
+		},
+		OnError: func(ev *CompileEvent) {
+			if trace.IsEnabled() {
+				trace.Log(context.Background(), "reonce.error", ev.Err.Error())
+			}
+		},
+	}
+}
@@ -0,0 +1,160 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"runtime/trace"
+	"strings"
+	"testing"
+)
+
+// recordHooks returns Hooks that append the name of each hook called to
+// calls and the events passed to them to events.
+func recordHooks(calls *[]string, events *[]*CompileEvent) *Hooks {
+	return &Hooks{
+		OnFirstUse: func(ev *CompileEvent) func() {
+			*calls = append(*calls, "OnFirstUse")
+			*events = append(*events, ev)
+			return func() { *calls = append(*calls, "done") }
+		},
+		OnCompile: func(ev *CompileEvent) {
+			*calls = append(*calls, "OnCompile")
+			*events = append(*events, ev)
+		},
+		OnError: func(ev *CompileEvent) {
+			*calls = append(*calls, "OnError")
+			*events = append(*events, ev)
+		},
+	}
+}
+
+func TestHooks(t *testing.T) {
+	var calls []string
+	var events []*CompileEvent
+	h := recordHooks(&calls, &events)
+
+	re := NewWithOptions(`a+`, Options{POSIX: true, Hooks: h})
+	if len(calls) != 0 {
+		t.Fatalf("hooks called before first use: %q", calls)
+	}
+	re.MatchString("a")
+	re.MatchString("a")
+	if got := strings.Join(calls, ","); got != "OnFirstUse,done,OnCompile" {
+		t.Errorf("calls = %s; want: %s", got, "OnFirstUse,done,OnCompile")
+	}
+	for _, ev := range events {
+		if ev.Expr != `a+` || !ev.POSIX || ev.Err != nil || !strings.HasSuffix(ev.File, "hooks_test.go") || ev.Line == 0 {
+			t.Errorf("event: %+v", ev)
+		}
+	}
+	if events[0].Duration != 0 || events[1].Duration != re.compileTime {
+		t.Errorf("Duration: OnFirstUse: %s OnCompile: %s want: %s",
+			events[0].Duration, events[1].Duration, re.compileTime)
+	}
+
+	calls, events = nil, nil
+	bad := NewWithOptions(`a(`, Options{Hooks: h})
+	bad.Compile()
+	if got := strings.Join(calls, ","); got != "OnFirstUse,done,OnError" {
+		t.Errorf("calls = %s; want: %s", got, "OnFirstUse,done,OnError")
+	}
+	if ev := events[len(events)-1]; ev.Err == nil || ev.Err != bad.Err() {
+		t.Errorf("OnError: Err = %v; want: %v", ev.Err, bad.Err())
+	}
+}
+
+func TestSetHooks(t *testing.T) {
+	var calls []string
+	var events []*CompileEvent
+	h := recordHooks(&calls, &events)
+	if prev := SetHooks(h); prev != nil {
+		t.Errorf("SetHooks: prev = %p; want: nil", prev)
+	}
+	defer SetHooks(nil)
+
+	New(`b+`).Compile()
+	if got := strings.Join(calls, ","); got != "OnFirstUse,done,OnCompile" {
+		t.Errorf("calls = %s; want: %s", got, "OnFirstUse,done,OnCompile")
+	}
+
+	// Options.Hooks replace the package level Hooks.
+	calls = nil
+	NewWithOptions(`c+`, Options{Hooks: &Hooks{}}).Compile()
+	if len(calls) != 0 {
+		t.Errorf("package level hooks called: %q", calls)
+	}
+
+	if prev := SetHooks(nil); prev != h {
+		t.Errorf("SetHooks: prev = %p; want: %p", prev, h)
+	}
+	calls = nil
+	New(`d+`).Compile()
+	if len(calls) != 0 {
+		t.Errorf("hooks called after SetHooks(nil): %q", calls)
+	}
+}
+
+func TestLogHooks(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	h := LogHooks(logger)
+
+	NewWithOptions(`a+`, Options{Hooks: h}).Compile()
+	NewWithOptions(`a(`, Options{Hooks: h}).Compile()
+
+	var records []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var m map[string]any
+		if err := json.Unmarshal(line, &m); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, m)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d log records; want: 2\n%s", len(records), buf.String())
+	}
+	if r := records[0]; r["level"] != "DEBUG" || r["expr"] != "a+" || r["error"] != nil {
+		t.Errorf("compile record: %v", r)
+	}
+	if r := records[1]; r["level"] != "ERROR" || r["expr"] != "a(" || r["error"] == nil {
+		t.Errorf("error record: %v", r)
+	}
+	for _, r := range records {
+		if file, _ := r["file"].(string); !strings.HasSuffix(file, "hooks_test.go") || r["line"] == nil {
+			t.Errorf("missing declaration site: %v", r)
+		}
+	}
+
+	// Debug records are not built if the level is disabled.
+	buf.Reset()
+	h = LogHooks(slog.New(slog.NewJSONHandler(&buf, nil)))
+	NewWithOptions(`a+`, Options{Hooks: h}).Compile()
+	if buf.Len() != 0 {
+		t.Errorf("unexpected log output: %s", buf.String())
+	}
+}
+
+func TestTraceHooks(t *testing.T) {
+	h := TraceHooks()
+	if done := h.OnFirstUse(&CompileEvent{Expr: "x"}); done != nil {
+		t.Error("OnFirstUse should return nil when tracing is disabled")
+	}
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skip("tracing is already enabled:", err)
+	}
+	NewWithOptions(`trace[0-9]+`, Options{Hooks: h}).Compile()
+	NewWithOptions(`trace(`, Options{Hooks: h}).Compile()
+	trace.Stop()
+
+	for _, s := range []string{"reonce.Compile", "reonce.expr", "trace[0-9]+", "reonce.error"} {
+		if !bytes.Contains(buf.Bytes(), []byte(s)) {
+			t.Errorf("trace does not contain %q", s)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+// Package unregistered allows packages in this module to create
+// reonce.Regexps that are not recorded in the reonce registry.
+package unregistered
+
+// New returns a lazily initialized *reonce.Regexp that is not added to the
+// reonce registry. It is set by package reonce and returns an any to avoid
+// an import cycle.
+var New func(expr string, posix bool) any
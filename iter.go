@@ -0,0 +1,129 @@
+//go:build go1.23
+
+package reonce
+
+import "iter"
+
+// All returns an iterator over the successive matches of the expression in
+// b. The matches are the same as those returned by FindAll, but they are
+// found one at a time so that iteration can stop early without finding all
+// matches first. Stopping after n matches yields the same matches as
+// FindAll(b, n).
+func (re *Regexp) All(b []byte) iter.Seq[[]byte] {
+	rx := re.re()
+	return func(yield func([]byte) bool) {
+		if statsEnabled.Load() {
+			re.record(familyFind)
+		}
+		re.allMatches(rx, nonNil(b), "", -1, false, func(m []int) bool {
+			return yield(b[m[0]:m[1]:m[1]])
+		})
+	}
+}
+
+// AllString returns an iterator over the successive matches of the
+// expression in s. It is the iterator form of FindAllString.
+func (re *Regexp) AllString(s string) iter.Seq[string] {
+	rx := re.re()
+	return func(yield func(string) bool) {
+		if statsEnabled.Load() {
+			re.record(familyFind)
+		}
+		re.allMatches(rx, nil, s, -1, false, func(m []int) bool {
+			return yield(s[m[0]:m[1]])
+		})
+	}
+}
+
+// AllIndex returns an iterator over the locations of the successive matches
+// of the expression in b. It is the iterator form of FindAllIndex.
+func (re *Regexp) AllIndex(b []byte) iter.Seq[[]int] {
+	rx := re.re()
+	return func(yield func([]int) bool) {
+		if statsEnabled.Load() {
+			re.record(familyFind)
+		}
+		re.allMatches(rx, nonNil(b), "", -1, false, yield)
+	}
+}
+
+// AllStringIndex returns an iterator over the locations of the successive
+// matches of the expression in s. It is the iterator form of
+// FindAllStringIndex.
+func (re *Regexp) AllStringIndex(s string) iter.Seq[[]int] {
+	rx := re.re()
+	return func(yield func([]int) bool) {
+		if statsEnabled.Load() {
+			re.record(familyFind)
+		}
+		re.allMatches(rx, nil, s, -1, false, yield)
+	}
+}
+
+// AllSubmatch returns an iterator over the successive matches of the
+// expression in b and the matches of its subexpressions. It is the iterator
+// form of FindAllSubmatch.
+func (re *Regexp) AllSubmatch(b []byte) iter.Seq[[][]byte] {
+	rx := re.re()
+	return func(yield func([][]byte) bool) {
+		if statsEnabled.Load() {
+			re.record(familyFind)
+		}
+		re.allMatches(rx, nonNil(b), "", -1, true, func(m []int) bool {
+			sub := make([][]byte, len(m)/2)
+			for i := range sub {
+				if m[2*i] >= 0 {
+					sub[i] = b[m[2*i]:m[2*i+1]:m[2*i+1]]
+				}
+			}
+			return yield(sub)
+		})
+	}
+}
+
+// AllStringSubmatch returns an iterator over the successive matches of the
+// expression in s and the matches of its subexpressions. It is the iterator
+// form of FindAllStringSubmatch.
+func (re *Regexp) AllStringSubmatch(s string) iter.Seq[[]string] {
+	rx := re.re()
+	return func(yield func([]string) bool) {
+		if statsEnabled.Load() {
+			re.record(familyFind)
+		}
+		re.allMatches(rx, nil, s, -1, true, func(m []int) bool {
+			sub := make([]string, len(m)/2)
+			for i := range sub {
+				if m[2*i] >= 0 {
+					sub[i] = s[m[2*i]:m[2*i+1]]
+				}
+			}
+			return yield(sub)
+		})
+	}
+}
+
+// AllSubmatchIndex returns an iterator over the locations of the successive
+// matches of the expression in b and the matches of its subexpressions. It
+// is the iterator form of FindAllSubmatchIndex.
+func (re *Regexp) AllSubmatchIndex(b []byte) iter.Seq[[]int] {
+	rx := re.re()
+	return func(yield func([]int) bool) {
+		if statsEnabled.Load() {
+			re.record(familyFind)
+		}
+		re.allMatches(rx, nonNil(b), "", -1, true, yield)
+	}
+}
+
+// AllStringSubmatchIndex returns an iterator over the locations of the
+// successive matches of the expression in s and the matches of its
+// subexpressions. It is the iterator form of FindAllStringSubmatchIndex.
+func (re *Regexp) AllStringSubmatchIndex(s string) iter.Seq[[]int] {
+	rx := re.re()
+	return func(yield func([]int) bool) {
+		if statsEnabled.Load() {
+			re.record(familyFind)
+		}
+		re.allMatches(rx, nil, s, -1, true, yield)
+	}
+}
@@ -0,0 +1,259 @@
+//go:build go1.23 && !reoncetest
+
+package reonce
+
+import (
+	"math/rand"
+	"reflect"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// matchTests are patterns and inputs used to compare the results of methods
+// that find successive matches against the regexp package.
+var matchTests = struct {
+	exprs  []string
+	posix  []string
+	inputs []string
+}{
+	exprs: []string{
+		``,
+		`a`,
+		`a*`,
+		`a*?`,
+		`x*`,
+		`a|b`,
+		`(a)|(b)`,
+		`(?P<first>\w+)\s(?P<last>\w+)`,
+		`\b`,
+		`\B`,
+		`\bab`,
+		`\b\w+\b`,
+		`\Ba`,
+		`^`,
+		`^a`,
+		`(?m)^`,
+		`(?m)^a*`,
+		`(?m)^\w+$`,
+		`$`,
+		`(?m)$`,
+		`\A`,
+		`\Aa`,
+		`a\z`,
+		`(?s).`,
+		`.`,
+		`[^a]`,
+		`☺`,
+		`\p{Greek}+`,
+		`(|a)*`,
+		`(|a)+`,
+		`(a|)*?b`,
+		`((a)|b)+`,
+		`\b|a`,
+		`(?i)\bA`,
+	},
+	posix: []string{
+		`a*`,
+		`^a`,
+		`^`,
+		`a|ab`,
+		`(a|ab)(c|bcd)(d*)`,
+		`$`,
+	},
+	inputs: []string{
+		"",
+		"a",
+		"aaa",
+		"abab",
+		"ab ab  ab",
+		"b a\nab\n\naa",
+		"\na\n",
+		"xaxbx",
+		"John Smith Jane Doe",
+		"☺a☺ ☺",
+		"αβγ δ",
+		"\xffa\xfe\xff",
+		"a\x80b",
+		"abcd abbcd",
+		"  ",
+	},
+}
+
+// testRegexps returns pairs of Regexps and equivalent regexp.Regexps for
+// the matchTests patterns.
+func testRegexps() (res []*Regexp, rxs []*regexp.Regexp) {
+	for _, expr := range matchTests.exprs {
+		res = append(res, New(expr))
+		rxs = append(rxs, regexp.MustCompile(expr))
+
+		longest := regexp.MustCompile(expr)
+		longest.Longest()
+		res = append(res, NewWithOptions(expr, Options{Longest: true}))
+		rxs = append(rxs, longest)
+	}
+	for _, expr := range matchTests.posix {
+		res = append(res, NewPOSIX(expr))
+		rxs = append(rxs, regexp.MustCompilePOSIX(expr))
+
+		res = append(res, NewWithOptions(expr, Options{POSIX: true, DotNL: true}))
+		rxs = append(rxs, regexp.MustCompilePOSIX(expr))
+	}
+	return res, rxs
+}
+
+func TestAll(t *testing.T) {
+	res, rxs := testRegexps()
+	for i, re := range res {
+		rx := rxs[i]
+		for _, s := range matchTests.inputs {
+			b := []byte(s)
+			for _, n := range []int{-1, 1, 2, 3} {
+				limit := func(seq func(func([]int) bool)) [][]int {
+					var out [][]int
+					for m := range seq {
+						if n >= 0 && len(out) == n {
+							break
+						}
+						out = append(out, m)
+					}
+					return out
+				}
+				check := func(name string, got, want any) {
+					t.Helper()
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("%s (posix=%t longest=%t): %s(%q, %d):\ngot:  %v\nwant: %v",
+							re, re.opts.POSIX, re.opts.Longest, name, s, n, got, want)
+					}
+				}
+				check("AllIndex", limit(re.AllIndex(b)), rx.FindAllIndex(b, n))
+				check("AllStringIndex", limit(re.AllStringIndex(s)), rx.FindAllStringIndex(s, n))
+				check("AllSubmatchIndex", limit(re.AllSubmatchIndex(b)), rx.FindAllSubmatchIndex(b, n))
+				check("AllStringSubmatchIndex", limit(re.AllStringSubmatchIndex(s)),
+					rx.FindAllStringSubmatchIndex(s, n))
+			}
+			check := func(name string, got, want any) {
+				t.Helper()
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: %s(%q):\ngot:  %q\nwant: %q", re, name, s, got, want)
+				}
+			}
+			check("All", collect(re.All(b)), rx.FindAll(b, -1))
+			check("AllString", collect(re.AllString(s)), rx.FindAllString(s, -1))
+			check("AllSubmatch", collect(re.AllSubmatch(b)), rx.FindAllSubmatch(b, -1))
+			check("AllStringSubmatch", collect(re.AllStringSubmatch(s)), rx.FindAllStringSubmatch(s, -1))
+		}
+	}
+}
+
+// collect is like slices.Collect but returns nil for empty sequences to
+// match the FindAll methods.
+func collect[T any](seq func(func(T) bool)) []T {
+	s := slices.Collect(seq)
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+func TestAllNilInput(t *testing.T) {
+	re := New(`a*`)
+	if got := collect(re.All(nil)); !reflect.DeepEqual(got, re.FindAll(nil, -1)) {
+		t.Errorf("All(nil) = %q want: %q", got, re.FindAll(nil, -1))
+	}
+}
+
+func TestAllLongestAfterUse(t *testing.T) {
+	re := New(`\ba|\bab`)
+	collect(re.AllString("ab ab"))
+	re.Longest()
+	want := []string{"ab", "ab"}
+	if got := collect(re.AllString("ab ab")); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllString: got: %q want: %q", got, want)
+	}
+}
+
+func TestAllNeverMatch(t *testing.T) {
+	re := NewWithOptions(`(`, Options{OnError: NeverMatch(nil)})
+	for m := range re.AllString("(") {
+		t.Errorf("unexpected match: %q", m)
+	}
+}
+
+// randomMatchTest returns a random pattern built from atoms and a random
+// input built from the runes of chars (utf8.RuneError is replaced by an
+// invalid byte).
+func randomMatchTest(r *rand.Rand, atoms []string, chars string) (expr, s string) {
+	for i := r.Intn(4); i >= 0; i-- {
+		expr += atoms[r.Intn(len(atoms))]
+	}
+	if r.Intn(3) == 0 {
+		expr += "|" + atoms[r.Intn(len(atoms))]
+	}
+	runes := []rune(chars)
+	var b []byte
+	for i := r.Intn(8); i > 0; i-- {
+		if c := runes[r.Intn(len(runes))]; c == utf8.RuneError {
+			b = append(b, 0xff)
+		} else {
+			b = utf8.AppendRune(b, c)
+		}
+	}
+	return expr, string(b)
+}
+
+func TestAllRandom(t *testing.T) {
+	perl := []string{"a", "b", " ", `\b`, `\B`, "^", "$", "(?m:^)", "(?m:$)", "a*", "b+?",
+		"(a|b)", "(|a)", ".", `\w`, "☺", `\A`, `\z`, "(a*)", "[^a]"}
+	posix := []string{"a", "b", " ", "^", "$", "a*", "b+", "(a|ab)", "(|a)", ".",
+		"[[:alpha:]]", "(a*)", "[^a]", "(b|ba)"}
+	const chars = "ab ☺\n�"
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 2000; i++ {
+		expr, s := randomMatchTest(r, perl, chars)
+		rx := regexp.MustCompile(expr)
+		if got, want := collect(New(expr).AllStringSubmatchIndex(s)), rx.FindAllStringSubmatchIndex(s, -1); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q: AllStringSubmatchIndex(%q) = %v want: %v", expr, s, got, want)
+		}
+		rx.Longest()
+		re := NewWithOptions(expr, Options{Longest: true})
+		if got, want := collect(re.AllStringSubmatchIndex(s)), rx.FindAllStringSubmatchIndex(s, -1); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q (longest): AllStringSubmatchIndex(%q) = %v want: %v", expr, s, got, want)
+		}
+
+		expr, s = randomMatchTest(r, posix, chars)
+		rx = regexp.MustCompilePOSIX(expr)
+		if got, want := collect(NewPOSIX(expr).AllStringSubmatchIndex(s)), rx.FindAllStringSubmatchIndex(s, -1); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q (posix): AllStringSubmatchIndex(%q) = %v want: %v", expr, s, got, want)
+		}
+	}
+}
+
+func TestIteratorStats(t *testing.T) {
+	re := New(`a+`)
+	defer EnableStats(EnableStats(true))
+
+	seq := re.AllString("a a")
+	if s := re.Stats(); s.FindCalls != 0 {
+		t.Errorf("creating an iterator should not be recorded: %+v", s)
+	}
+	for range seq {
+	}
+	for range seq {
+		break
+	}
+	for range re.AllIndex([]byte("a")) {
+	}
+	for range re.AllStringSubmatch("a") {
+	}
+	s := re.Stats()
+	if s.FindCalls != 4 || s.FirstUse.IsZero() {
+		t.Errorf("Stats: FindCalls = %d FirstUse = %v; want: 4 and a time", s.FindCalls, s.FirstUse)
+	}
+	if s.Samples != 0 {
+		t.Errorf("Stats: iterators should not be sampled: %d samples", s.Samples)
+	}
+}
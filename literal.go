@@ -0,0 +1,185 @@
+package reonce
+
+import (
+	"bytes"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+// A literalMatcher matches patterns that are a literal string, optionally
+// anchored at the start or end of the text, such as `foo`, `^foo`, `foo$`
+// and `^foo$`, using the strings and bytes packages instead of the regexp
+// engine. The results are identical to those of the regexp package.
+type literalMatcher struct {
+	lit    string
+	blit   []byte
+	prefix bool // anchored at the start of the text (\A or ^ without m)
+	suffix bool // anchored at the end of the text (\z or $ without m)
+}
+
+// newLiteralMatcher returns a literalMatcher for the syntax tree of a
+// pattern or nil if the pattern is not a literal.
+func newLiteralMatcher(tree *syntax.Regexp) *literalMatcher {
+	var l literalMatcher
+	sub := []*syntax.Regexp{tree}
+	if tree.Op == syntax.OpConcat {
+		sub = tree.Sub
+	}
+	if len(sub) > 0 && sub[0].Op == syntax.OpBeginText {
+		l.prefix = true
+		sub = sub[1:]
+	}
+	if len(sub) > 0 && sub[len(sub)-1].Op == syntax.OpEndText {
+		l.suffix = true
+		sub = sub[:len(sub)-1]
+	}
+	if len(sub) != 1 || sub[0].Op != syntax.OpLiteral || sub[0].Flags&syntax.FoldCase != 0 {
+		return nil
+	}
+	for _, r := range sub[0].Rune {
+		// The regexp package matches invalid UTF-8 in the input as
+		// utf8.RuneError, which a substring search would not.
+		if r == utf8.RuneError {
+			return nil
+		}
+	}
+	l.lit = string(sub[0].Rune)
+	l.blit = []byte(l.lit)
+	return &l
+}
+
+// index returns the start of the leftmost match in b (or s, if b is nil)
+// that starts at or after pos, or -1 if there is no match.
+func (l *literalMatcher) index(b []byte, s string, pos int) int {
+	if l.prefix || l.suffix {
+		// Anchored literals match at most once, which is found when
+		// searching from the start of the text.
+		if pos > 0 {
+			return -1
+		}
+		var ok bool
+		var n int
+		switch {
+		case b != nil && l.prefix && l.suffix:
+			ok, n = bytes.Equal(b, l.blit), len(b)
+		case b != nil && l.prefix:
+			ok, n = bytes.HasPrefix(b, l.blit), len(b)
+		case b != nil:
+			ok, n = bytes.HasSuffix(b, l.blit), len(b)
+		case l.prefix && l.suffix:
+			ok, n = s == l.lit, len(s)
+		case l.prefix:
+			ok, n = strings.HasPrefix(s, l.lit), len(s)
+		default:
+			ok, n = strings.HasSuffix(s, l.lit), len(s)
+		}
+		switch {
+		case !ok:
+			return -1
+		case l.prefix:
+			return 0
+		default:
+			return n - len(l.lit)
+		}
+	}
+	var i int
+	if b != nil {
+		i = bytes.Index(b[pos:], l.blit)
+	} else {
+		i = strings.Index(s[pos:], l.lit)
+	}
+	if i < 0 {
+		return -1
+	}
+	return pos + i
+}
+
+// find returns the index of the leftmost match in b (or s, if b is nil).
+func (l *literalMatcher) find(b []byte, s string) []int {
+	i := l.index(b, s, 0)
+	if i < 0 {
+		return nil
+	}
+	return []int{i, i + len(l.lit)}
+}
+
+// findAll returns the indexes of up to n, or all if n < 0, successive
+// matches in b (or s, if b is nil). Since the literal is not empty matches
+// do not overlap and there are no empty matches to skip.
+func (l *literalMatcher) findAll(b []byte, s string, n int) [][]int {
+	var locs [][]int
+	for pos := 0; n < 0 || len(locs) < n; {
+		i := l.index(b, s, pos)
+		if i < 0 {
+			break
+		}
+		pos = i + len(l.lit)
+		locs = append(locs, []int{i, pos})
+	}
+	return locs
+}
+
+// replaceAll is the same as the replaceAll method of regexp.Regexp. It
+// returns a copy of bsrc (or src, if bsrc is nil) with each match replaced
+// by the result of calling repl with the index of the match.
+func (l *literalMatcher) replaceAll(bsrc []byte, src string, repl func(dst []byte, start, end int) []byte) []byte {
+	var buf []byte
+	last := 0
+	for {
+		i := l.index(bsrc, src, last)
+		if i < 0 {
+			break
+		}
+		if bsrc != nil {
+			buf = append(buf, bsrc[last:i]...)
+		} else {
+			buf = append(buf, src[last:i]...)
+		}
+		last = i + len(l.lit)
+		buf = repl(buf, i, last)
+	}
+	if bsrc != nil {
+		buf = append(buf, bsrc[last:]...)
+	} else {
+		buf = append(buf, src[last:]...)
+	}
+	return buf
+}
+
+// replaceAllLiteral returns a copy of bsrc (or src, if bsrc is nil) with
+// each match replaced by repl.
+func replaceAllLiteral[T string | []byte](l *literalMatcher, bsrc []byte, src string, repl T) []byte {
+	return l.replaceAll(bsrc, src, func(dst []byte, _, _ int) []byte {
+		return append(dst, repl...)
+	})
+}
+
+// split is the same as the Split method of regexp.Regexp.
+func (l *literalMatcher) split(s string, n int) []string {
+	if n == 0 {
+		return nil
+	}
+	if len(s) == 0 {
+		return []string{""}
+	}
+	matches := l.findAll(nil, s, n)
+	strs := make([]string, 0, len(matches))
+
+	beg := 0
+	end := 0
+	for _, match := range matches {
+		if n > 0 && len(strs) >= n-1 {
+			break
+		}
+		end = match[0]
+		if match[1] != 0 {
+			strs = append(strs, s[beg:end])
+		}
+		beg = match[1]
+	}
+	if end != len(s) {
+		strs = append(strs, s[beg:])
+	}
+	return strs
+}
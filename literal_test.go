@@ -0,0 +1,128 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLiteralMatcherPatterns(t *testing.T) {
+	tests := []struct {
+		expr   string
+		opts   Options
+		lit    string
+		prefix bool
+		suffix bool
+	}{
+		{expr: `foo`, lit: "foo"},
+		{expr: `a`, lit: "a"},
+		{expr: `^foo`, lit: "foo", prefix: true},
+		{expr: `\Afoo`, lit: "foo", prefix: true},
+		{expr: `foo$`, lit: "foo", suffix: true},
+		{expr: `foo\z`, lit: "foo", suffix: true},
+		{expr: `^foo$`, lit: "foo", prefix: true, suffix: true},
+		{expr: `a\.b☺`, lit: "a.b☺"},
+		{expr: `(?:foo)`, lit: "foo"},
+		{expr: `a.b`, opts: Options{Literal: true}, lit: "a.b"},
+		{expr: `^a$`, opts: Options{POSIX: true, Literal: true}, lit: "^a$"},
+		{expr: `foo`, opts: Options{POSIX: true}, lit: "foo"},
+
+		// not literals
+		{expr: ``},
+		{expr: `^`},
+		{expr: `^$`},
+		{expr: `(foo)`},
+		{expr: `fo+`},
+		{expr: `foo|bar`},
+		{expr: `(?i)foo`},
+		{expr: `foo`, opts: Options{FoldCase: true}},
+		{expr: `(?m)^foo`},
+		{expr: `^foo`, opts: Options{MultiLine: true}},
+		{expr: `^foo`, opts: Options{POSIX: true}},
+		{expr: `\x{FFFD}`},
+		{expr: `a\bb`},
+	}
+	for _, tt := range tests {
+		re := NewWithOptions(tt.expr, tt.opts)
+		re.MustCompile()
+		var want *literalMatcher
+		if tt.lit != "" {
+			want = &literalMatcher{lit: tt.lit, blit: []byte(tt.lit), prefix: tt.prefix, suffix: tt.suffix}
+		}
+		if !reflect.DeepEqual(re.lit, want) {
+			t.Errorf("%q (%+v): literalMatcher = %+v want: %+v", tt.expr, tt.opts, re.lit, want)
+		}
+	}
+}
+
+func TestLiteralMatcher(t *testing.T) {
+	exprs := []string{`a`, `aa`, `ab`, `^a`, `a$`, `^a$`, `^ab`, `ab$`, `☺`, `a☺`, `\xff`}
+	inputs := []string{
+		"",
+		"a",
+		"aa",
+		"aaa",
+		"ab",
+		"abab",
+		"bab",
+		"☺a☺",
+		"a\xffa",
+		"\xe2\x98a☺",
+		"ÿ",
+		"a\na",
+	}
+	for _, expr := range exprs {
+		re := New(expr)
+		rx := regexp.MustCompile(expr)
+		if re.MustCompile(); re.lit == nil {
+			t.Fatalf("%q: expected a literalMatcher", expr)
+		}
+		for _, s := range inputs {
+			b := []byte(s)
+			check := func(name string, got, want any) {
+				t.Helper()
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%q: %s(%q):\ngot:  %q\nwant: %q", expr, name, s, got, want)
+				}
+			}
+			check("Match", re.Match(b), rx.Match(b))
+			check("MatchString", re.MatchString(s), rx.MatchString(s))
+			check("Find", re.Find(b), rx.Find(b))
+			check("FindString", re.FindString(s), rx.FindString(s))
+			check("FindIndex", re.FindIndex(b), rx.FindIndex(b))
+			check("FindStringIndex", re.FindStringIndex(s), rx.FindStringIndex(s))
+			check("ReplaceAll", re.ReplaceAll(b, []byte("x")), rx.ReplaceAll(b, []byte("x")))
+			check("ReplaceAll$", re.ReplaceAll(b, []byte("<$0>")), rx.ReplaceAll(b, []byte("<$0>")))
+			check("ReplaceAllString", re.ReplaceAllString(s, "x"), rx.ReplaceAllString(s, "x"))
+			check("ReplaceAllString$", re.ReplaceAllString(s, "<$0>"), rx.ReplaceAllString(s, "<$0>"))
+			check("ReplaceAllLiteral", re.ReplaceAllLiteral(b, []byte("$")), rx.ReplaceAllLiteral(b, []byte("$")))
+			check("ReplaceAllLiteralString", re.ReplaceAllLiteralString(s, "$"), rx.ReplaceAllLiteralString(s, "$"))
+			// The match passed to the callback aliases b, so copy it
+			// before appending to avoid writing into b.
+			double := func(m []byte) []byte {
+				return append(append([]byte(nil), m...), m...)
+			}
+			check("ReplaceAllFunc", re.ReplaceAllFunc(b, double), rx.ReplaceAllFunc(b, double))
+			check("ReplaceAllStringFunc", re.ReplaceAllStringFunc(s, strings.ToUpper),
+				rx.ReplaceAllStringFunc(s, strings.ToUpper))
+			for _, n := range []int{-1, 0, 1, 2, 3} {
+				check("FindAll", re.FindAll(b, n), rx.FindAll(b, n))
+				check("FindAllString", re.FindAllString(s, n), rx.FindAllString(s, n))
+				check("FindAllIndex", re.FindAllIndex(b, n), rx.FindAllIndex(b, n))
+				check("FindAllStringIndex", re.FindAllStringIndex(s, n), rx.FindAllStringIndex(s, n))
+				check("Split", re.Split(s, n), rx.Split(s, n))
+			}
+		}
+		// nil input
+		if got, want := re.FindAll(nil, -1), rx.FindAll(nil, -1); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: FindAll(nil) = %q want: %q", expr, got, want)
+		}
+		if got, want := re.ReplaceAll(nil, nil), rx.ReplaceAll(nil, nil); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: ReplaceAll(nil) = %q want: %q", expr, got, want)
+		}
+	}
+}
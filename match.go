@@ -0,0 +1,194 @@
+package reonce
+
+import (
+	"encoding/json"
+)
+
+// A Match is a match of a Regexp in a string and the matches of its
+// subexpressions, or groups. Group 0 is the match of the entire expression
+// and groups 1 through NumSubexp are the parenthesized subexpressions, as
+// numbered by regexp.Regexp.SubexpIndex. Indexes are byte offsets into the
+// string that was searched.
+type Match struct {
+	input string
+	loc   []int    // pairs of submatch indexes as returned by FindStringSubmatchIndex
+	names []string // shared with the regexp.Regexp, must not be modified
+}
+
+func newMatch(s string, loc []int, names []string) *Match {
+	return &Match{input: s, loc: loc, names: names}
+}
+
+// FindMatch returns the leftmost match of the Regexp in s as a *Match.
+// A return value of nil indicates no match.
+func (re *Regexp) FindMatch(s string) *Match {
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	loc := rx.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return nil
+	}
+	return newMatch(s, loc, rx.SubexpNames())
+}
+
+// FindAllMatches is the 'All' version of FindMatch; it returns a slice of
+// all successive matches of the expression, as defined by the 'All'
+// description in the package comment of the regexp package.
+// A return value of nil indicates no match.
+func (re *Regexp) FindAllMatches(s string, n int) []*Match {
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	locs := rx.FindAllStringSubmatchIndex(s, n)
+	if locs == nil {
+		return nil
+	}
+	names := rx.SubexpNames()
+	matches := make([]*Match, len(locs))
+	for i, loc := range locs {
+		matches[i] = newMatch(s, loc, names)
+	}
+	return matches
+}
+
+// Len returns the number of groups of the match, including group 0, which
+// is one more than the number of parenthesized subexpressions of the Regexp.
+func (m *Match) Len() int { return len(m.loc) / 2 }
+
+// String returns the text of the match. It is the same as Group(0).
+func (m *Match) String() string { return m.input[m.loc[0]:m.loc[1]] }
+
+// Start returns the index of the start of the match in the input string.
+func (m *Match) Start() int { return m.loc[0] }
+
+// End returns the index of the end of the match in the input string.
+func (m *Match) End() int { return m.loc[1] }
+
+// Index returns the indexes of the start and end of group i in the input
+// string. Both are -1 if group i did not take part in the match or is not a
+// valid group.
+func (m *Match) Index(i int) (start, end int) {
+	if i < 0 || i >= m.Len() {
+		return -1, -1
+	}
+	return m.loc[2*i], m.loc[2*i+1]
+}
+
+// Matched reports whether group i took part in the match. This
+// distinguishes a group that matched the empty string from one that did
+// not match at all, for which Group returns the empty string as well.
+func (m *Match) Matched(i int) bool {
+	start, _ := m.Index(i)
+	return start >= 0
+}
+
+// Group returns the text of group i. It returns the empty string if group i
+// did not take part in the match or is not a valid group.
+func (m *Match) Group(i int) string {
+	start, end := m.Index(i)
+	if start < 0 {
+		return ""
+	}
+	return m.input[start:end]
+}
+
+// Names returns the names of the groups of the match. Names[0] is always
+// the empty string, as are the names of unnamed groups. The slice should
+// not be modified.
+func (m *Match) Names() []string { return m.names }
+
+// GroupIndex returns the index of the first group with the given name, or
+// -1 if there is no group with that name.
+func (m *Match) GroupIndex(name string) int {
+	if name != "" {
+		for i, s := range m.names {
+			if name == s {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Named returns the text of the group with the given name. It returns the
+// empty string if there is no such group or it did not take part in the
+// match. Use NamedMatched to distinguish these cases from a group that
+// matched the empty string.
+func (m *Match) Named(name string) string {
+	return m.Group(m.GroupIndex(name))
+}
+
+// NamedMatched reports whether the group with the given name exists and
+// took part in the match.
+func (m *Match) NamedMatched(name string) bool {
+	return m.Matched(m.GroupIndex(name))
+}
+
+// Map returns a map of the names of the named groups that took part in the
+// match to their text. Named groups that did not take part in the match are
+// not included.
+func (m *Match) Map() map[string]string {
+	mp := make(map[string]string)
+	for i, name := range m.names {
+		if name != "" && m.Matched(i) {
+			if _, dup := mp[name]; !dup {
+				mp[name] = m.Group(i)
+			}
+		}
+	}
+	return mp
+}
+
+// jsonMatch is the JSON representation of a Match.
+type jsonMatch struct {
+	Match  string       `json:"match"`
+	Start  int          `json:"start"`
+	End    int          `json:"end"`
+	Groups []*jsonGroup `json:"groups"`
+}
+
+// jsonGroup is the JSON representation of a group of a Match, a nil
+// *jsonGroup is used for groups that did not take part in the match.
+type jsonGroup struct {
+	Name  string `json:"name,omitempty"`
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// MarshalJSON implements json.Marshaler. A Match is encoded as an object
+// containing the text and indexes of the match and an array of its
+// parenthesized subexpressions (groups 1 and above). Groups that did not
+// take part in the match are encoded as null.
+//
+//	{
+//		"match": "k=v",
+//		"start": 0,
+//		"end": 3,
+//		"groups": [
+//			{"name": "key", "text": "k", "start": 0, "end": 1},
+//			null
+//		]
+//	}
+func (m *Match) MarshalJSON() ([]byte, error) {
+	jm := jsonMatch{
+		Match:  m.String(),
+		Start:  m.Start(),
+		End:    m.End(),
+		Groups: make([]*jsonGroup, m.Len()-1),
+	}
+	for i := 1; i < m.Len(); i++ {
+		if start, end := m.Index(i); start >= 0 {
+			jm.Groups[i-1] = &jsonGroup{
+				Name:  m.names[i],
+				Text:  m.input[start:end],
+				Start: start,
+				End:   end,
+			}
+		}
+	}
+	return json.Marshal(jm)
+}
@@ -0,0 +1,90 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestFindMatch(t *testing.T) {
+	const expr = `(?P<key>\w+)=(?P<value>\w*)(;(?P<comment>.+))?`
+	re := New(expr)
+	rx := regexp.MustCompile(expr)
+
+	if m := re.FindMatch("no match"); m != nil {
+		t.Fatalf("FindMatch = %v want: nil", m)
+	}
+
+	s := " k="
+	m := re.FindMatch(s)
+	if m == nil {
+		t.Fatal("FindMatch returned nil")
+	}
+	if m.String() != "k=" || m.Start() != 1 || m.End() != 3 || m.Len() != 5 {
+		t.Errorf("Match = %q [%d:%d] Len: %d", m.String(), m.Start(), m.End(), m.Len())
+	}
+	loc := rx.FindStringSubmatchIndex(s)
+	for i := -1; i <= m.Len(); i++ {
+		start, end := m.Index(i)
+		if i >= 0 && i < m.Len() {
+			if start != loc[2*i] || end != loc[2*i+1] {
+				t.Errorf("Index(%d) = %d, %d want: %d, %d", i, start, end, loc[2*i], loc[2*i+1])
+			}
+		} else if start != -1 || end != -1 || m.Matched(i) || m.Group(i) != "" {
+			t.Errorf("invalid group %d: Index = %d, %d Matched: %t", i, start, end, m.Matched(i))
+		}
+	}
+	if m.Named("key") != "k" || m.Named("value") != "" || m.Named("nope") != "" {
+		t.Errorf("Named: key=%q value=%q nope=%q", m.Named("key"), m.Named("value"), m.Named("nope"))
+	}
+	if !m.NamedMatched("value") || m.NamedMatched("comment") || m.NamedMatched("nope") {
+		t.Error("NamedMatched: value should match and comment should not")
+	}
+	if got, want := m.Map(), map[string]string{"key": "k", "value": ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Map() = %q want: %q", got, want)
+	}
+	if got, want := m.Names(), rx.SubexpNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %q want: %q", got, want)
+	}
+}
+
+func TestFindAllMatches(t *testing.T) {
+	const expr = `(a)|(b)`
+	re := New(expr)
+	rx := regexp.MustCompile(expr)
+	for _, s := range []string{"", "c", "ab", "xaxbxa"} {
+		for _, n := range []int{-1, 0, 1, 2} {
+			want := rx.FindAllStringSubmatch(s, n)
+			got := re.FindAllMatches(s, n)
+			if (got == nil) != (want == nil) || len(got) != len(want) {
+				t.Fatalf("FindAllMatches(%q, %d) = %v want: %q", s, n, got, want)
+			}
+			for i, m := range got {
+				for j := 0; j < m.Len(); j++ {
+					if m.Group(j) != want[i][j] {
+						t.Errorf("FindAllMatches(%q, %d)[%d].Group(%d) = %q want: %q",
+							s, n, i, j, m.Group(j), want[i][j])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMatchJSON(t *testing.T) {
+	m := New(`(?P<key>\w+)=(\w+)(;(?P<comment>.+))?`).FindMatch("> k=v")
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"match":"k=v","start":2,"end":5,"groups":[` +
+		`{"name":"key","text":"k","start":2,"end":3},` +
+		`{"text":"v","start":4,"end":5},null,null]}`
+	if string(b) != want {
+		t.Errorf("MarshalJSON:\ngot:  %s\nwant: %s", b, want)
+	}
+}
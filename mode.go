@@ -0,0 +1,190 @@
+package reonce
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// A Mode controls when Regexps are compiled.
+type Mode int32
+
+const (
+	// ModeLazy compiles Regexps on first use. It is the default.
+	ModeLazy Mode = iota
+
+	// ModeEager compiles Regexps when they are created, which reports
+	// invalid patterns as early as possible. The ErrorHandler of a Regexp
+	// that fails to compile is called, so by default New panics. This is
+	// the default mode when built with the "reoncetest" tag.
+	ModeEager
+
+	// ModeBackground compiles Regexps in a background goroutine after they
+	// are created. Using a Regexp before it is compiled in the background
+	// compiles it, like ModeLazy.
+	ModeBackground
+)
+
+var modeNames = [...]string{
+	ModeLazy:       "lazy",
+	ModeEager:      "eager",
+	ModeBackground: "background",
+}
+
+func (m Mode) String() string {
+	if m >= 0 && int(m) < len(modeNames) {
+		return modeNames[m]
+	}
+	return "Mode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// parseMode returns the Mode named s, the names are case-insensitive.
+func parseMode(s string) (Mode, bool) {
+	for m, name := range modeNames {
+		if strings.EqualFold(s, name) {
+			return Mode(m), true
+		}
+	}
+	return 0, false
+}
+
+// mode is initialized before any Regexps are created by the package level
+// variables of other packages.
+var mode = newModeValue()
+
+// newModeValue returns the initial mode, which is ModeEager when built with
+// the "reoncetest" tag and ModeLazy otherwise, unless it is overridden by
+// the REONCE_MODE environment variable.
+func newModeValue() *atomic.Int32 {
+	m := ModeLazy
+	if mustCompile {
+		m = ModeEager
+	}
+	if s := os.Getenv("REONCE_MODE"); s != "" {
+		if v, ok := parseMode(s); ok {
+			m = v
+		} else {
+			slog.Error("reonce: invalid REONCE_MODE, using the default mode",
+				slog.String("REONCE_MODE", s), slog.String("mode", m.String()))
+		}
+	}
+	v := new(atomic.Int32)
+	v.Store(int32(m))
+	return v
+}
+
+// GetMode returns the current Mode.
+func GetMode() Mode { return Mode(mode.Load()) }
+
+// SetMode sets the Mode used to compile Regexps and returns the previous
+// Mode. The initial Mode is read from the REONCE_MODE environment variable,
+// which may be "lazy", "eager" or "background", and is otherwise ModeLazy
+// or, when built with the "reoncetest" tag, ModeEager. An unknown value is
+// logged with slog.Default() and the default Mode is used.
+//
+// The Mode applies to Regexps created after the call and upgrades the
+// registered Regexps (see Walk) that have not been compiled yet: ModeEager
+// compiles them before SetMode returns, calling the ErrorHandler of those
+// that fail in the order they were created, and ModeBackground queues them
+// to be compiled in the background. SetMode(ModeEager) must not be called
+// while registered Regexps are being decoded (see UnmarshalText).
+// SetMode panics if m is not a valid Mode.
+func SetMode(m Mode) (prev Mode) {
+	if m < 0 || int(m) >= len(modeNames) {
+		panic("reonce: invalid Mode: " + m.String())
+	}
+	prev = Mode(mode.Swap(int32(m)))
+	switch m {
+	case ModeEager:
+		var list []*Regexp
+		for _, re := range registered() {
+			if !re.Compiled() {
+				list = append(list, re)
+			}
+		}
+		compileParallel(context.Background(), list, 0, func(int, error) {})
+		for _, re := range list {
+			if re.err != nil {
+				re.handleError()
+			}
+		}
+	case ModeBackground:
+		for _, re := range registered() {
+			if !re.Compiled() {
+				compileInBackground(re)
+			}
+		}
+	}
+	return prev
+}
+
+// applyMode compiles the newly created Regexp re according to m.
+func (re *Regexp) applyMode(m Mode) {
+	switch m {
+	case ModeEager:
+		re.re()
+	case ModeBackground:
+		compileInBackground(re)
+	}
+}
+
+// background is the queue of Regexps waiting to be compiled in the
+// background. The queue is drained by a single goroutine that exits once
+// it is empty.
+var background struct {
+	mu        sync.Mutex
+	pending   []*Regexp
+	compiling *Regexp       // the Regexp being compiled, if any
+	done      chan struct{} // closed once compiling has been compiled
+	running   bool
+}
+
+func compileInBackground(re *Regexp) {
+	background.mu.Lock()
+	background.pending = append(background.pending, re)
+	if !background.running {
+		background.running = true
+		go compileBackground()
+	}
+	background.mu.Unlock()
+}
+
+func compileBackground() {
+	background.mu.Lock()
+	defer background.mu.Unlock()
+	for len(background.pending) > 0 {
+		re := background.pending[0]
+		background.pending[0] = nil
+		background.pending = background.pending[1:]
+		done := make(chan struct{})
+		background.compiling, background.done = re, done
+		background.mu.Unlock()
+		re.Compile()
+		background.mu.Lock()
+		background.compiling, background.done = nil, nil
+		close(done)
+	}
+	background.running = false
+}
+
+// cancelBackground removes re from the background compile queue and waits
+// for it to be compiled if it is being compiled in the background.
+func cancelBackground(re *Regexp) {
+	background.mu.Lock()
+	if i := slices.Index(background.pending, re); i >= 0 {
+		background.pending = slices.Delete(background.pending, i, i+1)
+	}
+	var done chan struct{}
+	if background.compiling == re {
+		done = background.done
+	}
+	background.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
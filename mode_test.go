@@ -0,0 +1,182 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setMode sets the Mode for the duration of the test.
+func setMode(t *testing.T, m Mode) {
+	prev := GetMode()
+	t.Cleanup(func() { mode.Store(int32(prev)) })
+	SetMode(m)
+}
+
+func TestModeString(t *testing.T) {
+	for _, m := range []Mode{ModeLazy, ModeEager, ModeBackground} {
+		got, ok := parseMode(m.String())
+		if !ok || got != m {
+			t.Errorf("parseMode(%q) = %v, %t; want: %v, true", m.String(), got, ok, m)
+		}
+	}
+	if m, ok := parseMode("EAGER"); !ok || m != ModeEager {
+		t.Errorf("parseMode(%q) = %v, %t; want: %v, true", "EAGER", m, ok, ModeEager)
+	}
+	if _, ok := parseMode("eagre"); ok {
+		t.Errorf("parseMode(%q) should fail", "eagre")
+	}
+	if s := Mode(7).String(); s != "Mode(7)" {
+		t.Errorf("Mode(7).String() = %q; want: %q", s, "Mode(7)")
+	}
+}
+
+func TestModeEnv(t *testing.T) {
+	tests := []struct {
+		env  string
+		want Mode
+	}{
+		{"", ModeLazy},
+		{"lazy", ModeLazy},
+		{"eager", ModeEager},
+		{"Background", ModeBackground},
+	}
+	for _, test := range tests {
+		t.Setenv("REONCE_MODE", test.env)
+		if got := Mode(newModeValue().Load()); got != test.want {
+			t.Errorf("REONCE_MODE=%s: mode = %v; want: %v", test.env, got, test.want)
+		}
+	}
+}
+
+func TestModeEnvInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	saved := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(saved) })
+
+	t.Setenv("REONCE_MODE", "eagre")
+	if got := Mode(newModeValue().Load()); got != ModeLazy {
+		t.Errorf("REONCE_MODE=eagre: mode = %v; want: %v", got, ModeLazy)
+	}
+	out := buf.String()
+	for _, s := range []string{"level=ERROR", "invalid REONCE_MODE", "REONCE_MODE=eagre", "mode=lazy"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("log output does not contain %q: %s", s, out)
+		}
+	}
+
+	buf.Reset()
+	t.Setenv("REONCE_MODE", "eager")
+	newModeValue()
+	if buf.Len() != 0 {
+		t.Errorf("unexpected log output for a valid mode: %s", buf.String())
+	}
+}
+
+func TestDefaultMode(t *testing.T) {
+	if os.Getenv("REONCE_MODE") != "" {
+		t.Skip("REONCE_MODE is set")
+	}
+	if m := GetMode(); m != ModeLazy {
+		t.Errorf("GetMode() = %v; want: %v", m, ModeLazy)
+	}
+}
+
+func TestSetModeEager(t *testing.T) {
+	old := New(`a+`)
+	withRegistry(t, old)
+	setMode(t, ModeEager)
+	if !old.Compiled() {
+		t.Error("SetMode(ModeEager) did not compile registered Regexp")
+	}
+	if re := New(`b+`); !re.Compiled() {
+		t.Error("New did not compile Regexp in ModeEager")
+	}
+	withCommandLine(t)
+	if re := Flag("re", `c+`, ""); !re.Compiled() {
+		t.Error("Flag did not compile Regexp in ModeEager")
+	}
+	func() {
+		defer func() {
+			if e := recover(); e == nil {
+				t.Error("New should panic on an invalid pattern in ModeEager")
+			}
+		}()
+		New(`a(`)
+	}()
+}
+
+func TestSetModeEagerInvalid(t *testing.T) {
+	bad := New(`a(`)
+	withRegistry(t, bad)
+	defer func() {
+		e := recover()
+		if e == nil {
+			t.Fatal("SetMode(ModeEager) should panic on an invalid registered pattern")
+		}
+		if err, ok := e.(*CompileError); !ok || err != bad.Err() {
+			t.Errorf("panic = %v; want: %v", e, bad.Err())
+		}
+	}()
+	setMode(t, ModeEager)
+}
+
+func waitCompiled(t *testing.T, re *Regexp) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !re.Compiled() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%q was not compiled in the background", re)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSetModeBackground(t *testing.T) {
+	old := New(`a+`)
+	withRegistry(t, old)
+	setMode(t, ModeBackground)
+	waitCompiled(t, old)
+
+	re := New(`b+`)
+	bad := New(`b(`) // errors are reported on first use
+	waitCompiled(t, re)
+	waitCompiled(t, bad)
+	if bad.Err() == nil {
+		t.Error("expected compile error")
+	}
+
+	// The pattern of a Flag is replaced when it is parsed.
+	withCommandLine(t)
+	if re := Flag("re", `c+`, ""); re.Compiled() {
+		t.Error("Flag should not be compiled in the background")
+	}
+}
+
+func TestSetModeLazy(t *testing.T) {
+	old := New(`a+`)
+	withRegistry(t, old)
+	setMode(t, ModeLazy)
+	if old.Compiled() {
+		t.Error("SetMode(ModeLazy) should not compile registered Regexps")
+	}
+	if re := New(`b+`); re.Compiled() {
+		t.Error("New should not compile Regexps in ModeLazy")
+	}
+}
+
+func TestSetModeInvalid(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("SetMode should panic with an invalid Mode")
+		}
+	}()
+	SetMode(-1)
+}
@@ -0,0 +1,227 @@
+package reonce
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+// The regexp package does not provide a way to search for a match starting
+// at an offset in the input, which is required to find successive matches
+// one at a time. Searching a slice of the input that starts at the offset
+// is only correct if the pattern does not contain assertions that depend on
+// the text preceding the match (^, \A, \b and \B). For patterns that do, an
+// offsetMatcher uses a modified Regexp that consumes the rune preceding the
+// offset as context.
+
+// An offsetMatcher finds the leftmost match of a Regexp that starts at or
+// after an offset in the input.
+type offsetMatcher struct {
+	// sensitive is true if the pattern contains assertions that depend on
+	// the text preceding a match.
+	sensitive bool
+
+	// first matches `\A(?s:.)(?s:.*?)(expr)` and finds the leftmost match
+	// after the context rune.
+	first *regexp.Regexp
+
+	// anchored matches `\A(?s:.)(expr)` with leftmost-longest semantics,
+	// it is only used if the Regexp is leftmost-longest since first can
+	// only be used to find the start of the match in that case.
+	anchored *regexp.Regexp
+}
+
+// contextSensitive reports if the parsed Regexp contains an assertion that
+// depends on the text preceding the current position.
+func contextSensitive(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpBeginLine, syntax.OpBeginText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	}
+	for _, sub := range re.Sub {
+		if contextSensitive(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCompiled parses the expression of rx, which was compiled using opts,
+// with the same syntax it was compiled with and returns the syntax tree and
+// the parse flags.
+func parseCompiled(rx *regexp.Regexp, opts *Options) (*syntax.Regexp, syntax.Flags) {
+	flags := syntax.Perl
+	if opts.posixSyntax() {
+		flags = syntax.POSIX
+	}
+	tree, err := syntax.Parse(rx.String(), flags)
+	if err != nil {
+		panic("reonce: parsing compiled Regexp: " + err.Error())
+	}
+	return tree, flags
+}
+
+func newOffsetMatcher(rx *regexp.Regexp, opts *Options) *offsetMatcher {
+	expr := rx.String()
+	tree, flags := parseCompiled(rx, opts)
+	m := &offsetMatcher{sensitive: contextSensitive(tree)}
+	if !m.sensitive {
+		return m
+	}
+	if flags == syntax.POSIX {
+		// The wrappers use Perl syntax.
+		expr = tree.String()
+	}
+	m.first = regexp.MustCompile(`\A(?s:.)(?s:.*?)(` + expr + `)`)
+	if opts.POSIX || opts.Longest {
+		m.anchored = regexp.MustCompile(`\A(?s:.)(` + expr + `)`)
+		m.anchored.Longest()
+	}
+	return m
+}
+
+// offsetMatcher returns the offsetMatcher of re, which must be compiled.
+func (re *Regexp) offsetMatcher() *offsetMatcher {
+	m := re.offset.Load()
+	if m == nil {
+		// Racing goroutines may create duplicate matchers, which is fine.
+		m = newOffsetMatcher(re.rx, &re.opts)
+		re.offset.Store(m)
+	}
+	return m
+}
+
+// findAt returns the leftmost match of rx in b (or s, if b is nil) that
+// starts at or after pos. The text before pos is taken into account the
+// same way it is by regexp.Regexp.FindAll. If sub is true the submatches
+// are returned as well. The returned indexes are relative to the start of
+// the input.
+func (m *offsetMatcher) findAt(rx *regexp.Regexp, b []byte, s string, pos int, sub bool) []int {
+	if pos == 0 || !m.sensitive {
+		var loc []int
+		switch {
+		case b != nil && sub:
+			loc = rx.FindSubmatchIndex(b[pos:])
+		case b != nil:
+			loc = rx.FindIndex(b[pos:])
+		case sub:
+			loc = rx.FindStringSubmatchIndex(s[pos:])
+		default:
+			loc = rx.FindStringIndex(s[pos:])
+		}
+		return shiftIndex(loc, pos)
+	}
+
+	start := pos - lastRuneWidth(b, s, pos)
+	loc := findSubmatchIndex(m.first, b, s, start)
+	if loc == nil {
+		return nil
+	}
+	loc = loc[2:] // remove the context rune
+	if m.anchored != nil {
+		// Find the longest match at the start of the leftmost match.
+		p := start + loc[0]
+		start = p - lastRuneWidth(b, s, p)
+		loc = findSubmatchIndex(m.anchored, b, s, start)[2:]
+	}
+	if !sub {
+		loc = loc[:2]
+	}
+	return shiftIndex(loc, start)
+}
+
+func findSubmatchIndex(rx *regexp.Regexp, b []byte, s string, start int) []int {
+	if b != nil {
+		return rx.FindSubmatchIndex(b[start:])
+	}
+	return rx.FindStringSubmatchIndex(s[start:])
+}
+
+// lastRuneWidth returns the width of the rune ending at pos.
+func lastRuneWidth(b []byte, s string, pos int) int {
+	var w int
+	if b != nil {
+		_, w = utf8.DecodeLastRune(b[:pos])
+	} else {
+		_, w = utf8.DecodeLastRuneInString(s[:pos])
+	}
+	return w
+}
+
+// shiftIndex adds n to all matched indexes in loc.
+func shiftIndex(loc []int, n int) []int {
+	if n != 0 {
+		for i, x := range loc {
+			if x >= 0 {
+				loc[i] = x + n
+			}
+		}
+	}
+	return loc
+}
+
+// allMatches calls deliver with the index (or submatch index if sub is true)
+// of successive matches of rx, the compiled Regexp of re, in b (or s if b is
+// nil) until deliver returns
+// false or n matches have been delivered, if n >= 0. The matches are the
+// same as those returned by the FindAll methods of regexp.Regexp, but are
+// found one at a time.
+func (re *Regexp) allMatches(rx *regexp.Regexp, b []byte, s string, n int, sub bool, deliver func(loc []int) bool) {
+	if re.err != nil || n == 0 {
+		return // the ErrorHandler did not panic so never match
+	}
+	m := re.offsetMatcher()
+	end := len(s)
+	if b != nil {
+		end = len(b)
+	}
+	for pos, prevMatchEnd := 0, -1; pos <= end; {
+		loc := m.findAt(rx, b, s, pos, sub)
+		if loc == nil {
+			break
+		}
+		accept := true
+		if loc[1] == pos {
+			// We've found an empty match.
+			if loc[0] == prevMatchEnd {
+				// We don't allow an empty match right
+				// after a previous match, so ignore it.
+				accept = false
+			}
+			var width int
+			if b != nil {
+				_, width = utf8.DecodeRune(b[pos:])
+			} else {
+				_, width = utf8.DecodeRuneInString(s[pos:])
+			}
+			if width > 0 {
+				pos += width
+			} else {
+				pos = end + 1
+			}
+		} else {
+			pos = loc[1]
+		}
+		prevMatchEnd = loc[1]
+
+		if accept {
+			if !deliver(loc) {
+				return
+			}
+			if n > 0 {
+				if n--; n == 0 {
+					return
+				}
+			}
+		}
+	}
+}
+
+// nonNil returns b or an empty slice if b is nil, since allMatches uses
+// a nil slice to indicate that the input is a string.
+func nonNil(b []byte) []byte {
+	if b == nil {
+		return []byte{}
+	}
+	return b
+}
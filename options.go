@@ -0,0 +1,136 @@
+package reonce
+
+import (
+	"regexp"
+	"regexp/syntax"
+)
+
+// Options configure how a Regexp created by NewWithOptions is compiled. The
+// options are applied when the Regexp is lazily compiled, which allows the
+// Regexp to be configured when it is declared and remain safe for
+// concurrent use.
+type Options struct {
+	// POSIX restricts the syntax to POSIX ERE (egrep) and uses
+	// leftmost-longest matching, see regexp.CompilePOSIX. Note that
+	// POSIX syntax is always multi-line (^ and $ match at line breaks).
+	POSIX bool
+
+	// Longest makes searches prefer the leftmost-longest match, see
+	// regexp.Regexp.Longest.
+	Longest bool
+
+	// FoldCase enables case-insensitive matching (syntax.FoldCase and
+	// the i flag).
+	FoldCase bool
+
+	// Literal treats the pattern as a literal string instead of a regular
+	// expression (syntax.Literal).
+	Literal bool
+
+	// DotNL allows . to match a newline (syntax.DotNL and the s flag).
+	DotNL bool
+
+	// MultiLine allows ^ and $ to match at the beginning and end of lines
+	// in addition to the beginning and end of text (clears
+	// syntax.OneLine and sets the m flag).
+	MultiLine bool
+
+	// NonGreedy swaps the meaning of x* and x*?, x+ and x+?, etc.
+	// (syntax.NonGreedy and the U flag).
+	NonGreedy bool
+
+	// OnError, if set, is called instead of the package level ErrorHandler
+	// when the Regexp fails to compile and is used by a method that cannot
+	// return an error. See ErrorHandler.
+	OnError ErrorHandler
+
+	// Hooks, if set, are called instead of the package level Hooks when
+	// the Regexp is compiled. See Hooks.
+	Hooks *Hooks
+}
+
+// flags returns the parse flags of the options, excluding the base Perl
+// or POSIX flags.
+func (o *Options) flags() syntax.Flags {
+	var flags syntax.Flags
+	if o.FoldCase {
+		flags |= syntax.FoldCase
+	}
+	if o.Literal {
+		flags |= syntax.Literal
+	}
+	if o.DotNL {
+		flags |= syntax.DotNL
+	}
+	if o.NonGreedy {
+		flags |= syntax.NonGreedy
+	}
+	return flags
+}
+
+// inlineFlags returns the Perl inline flags for the options, such as "(?is)".
+func (o *Options) inlineFlags() string {
+	var b []byte
+	if o.FoldCase {
+		b = append(b, 'i')
+	}
+	if o.MultiLine {
+		b = append(b, 'm')
+	}
+	if o.DotNL {
+		b = append(b, 's')
+	}
+	if o.NonGreedy {
+		b = append(b, 'U')
+	}
+	if len(b) == 0 {
+		return ""
+	}
+	return "(?" + string(b) + ")"
+}
+
+// posixSyntax reports if the options compile the pattern with
+// regexp.CompilePOSIX. Otherwise, Perl syntax is used.
+func (o *Options) posixSyntax() bool {
+	return o.POSIX && o.flags() == 0
+}
+
+// compile compiles expr using the options.
+func (o *Options) compile(expr string) (*regexp.Regexp, error) {
+	var rx *regexp.Regexp
+	var err error
+	flags := o.flags()
+	switch {
+	case o.posixSyntax():
+		rx, err = regexp.CompilePOSIX(expr)
+	case flags == 0 && !o.MultiLine:
+		rx, err = regexp.Compile(expr)
+	case o.POSIX:
+		// POSIX syntax does not support inline flags so parse the pattern
+		// and compile its Perl equivalent.
+		var tree *syntax.Regexp
+		tree, err = syntax.Parse(expr, syntax.POSIX|flags)
+		if err == nil {
+			rx, err = regexp.Compile(tree.String())
+		}
+		if err == nil {
+			rx.Longest()
+		}
+	default:
+		// Parse first so that errors refer to expr and not the
+		// rewritten pattern.
+		if _, err = syntax.Parse(expr, syntax.Perl|flags); err == nil {
+			if o.Literal {
+				expr = regexp.QuoteMeta(expr)
+			}
+			rx, err = regexp.Compile(o.inlineFlags() + expr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if o.Longest {
+		rx.Longest()
+	}
+	return rx, nil
+}
@@ -0,0 +1,113 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"testing"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	tests := []struct {
+		expr string
+		opts Options
+		want *regexp.Regexp // equivalent stdlib Regexp
+	}{
+		{`a+`, Options{}, regexp.MustCompile(`a+`)},
+		{`a+`, Options{POSIX: true}, regexp.MustCompilePOSIX(`a+`)},
+		{`a|ab`, Options{Longest: true}, func() *regexp.Regexp {
+			re := regexp.MustCompile(`a|ab`)
+			re.Longest()
+			return re
+		}()},
+		{`abc`, Options{FoldCase: true}, regexp.MustCompile(`(?i)abc`)},
+		{`a.c`, Options{DotNL: true}, regexp.MustCompile(`(?s)a.c`)},
+		{`^a$`, Options{MultiLine: true}, regexp.MustCompile(`(?m)^a$`)},
+		{`a+`, Options{NonGreedy: true}, regexp.MustCompile(`a+?`)},
+		{`a.c+`, Options{Literal: true}, regexp.MustCompile(`a\.c\+`)},
+		{`a.c`, Options{Literal: true, FoldCase: true}, regexp.MustCompile(`(?i)a\.c`)},
+		{`a(b|c)d`, Options{POSIX: true, FoldCase: true}, func() *regexp.Regexp {
+			re := regexp.MustCompile(`(?i)a(b|c)d`)
+			re.Longest()
+			return re
+		}()},
+		{`^x.y$`, Options{POSIX: true, DotNL: true}, nil},
+		{`a.b`, Options{POSIX: true, Literal: true}, regexp.MustCompilePOSIX(`a\.b`)},
+	}
+	inputs := []string{
+		"", "a", "aaa", "ab", "abc", "ABC", "aBc", "a\nc", "abcabc", "a.c+", "A.C",
+		"x\ny", "b\na\nc", "a.b", "axb", "abd", "ACD", "AbD",
+	}
+	for _, test := range tests {
+		re := NewWithOptions(test.expr, test.opts)
+		if err := re.Compile(); err != nil {
+			t.Fatalf("%q %+v: %v", test.expr, test.opts, err)
+		}
+		if re.String() != test.expr {
+			t.Errorf("String() = %q want: %q", re.String(), test.expr)
+		}
+		if re.POSIX() != test.opts.POSIX {
+			t.Errorf("%q %+v: POSIX() = %t", test.expr, test.opts, re.POSIX())
+		}
+		if test.want == nil {
+			continue
+		}
+		for _, s := range inputs {
+			got := re.FindAllStringSubmatchIndex(s, -1)
+			want := test.want.FindAllStringSubmatchIndex(s, -1)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%q %+v: FindAllStringSubmatchIndex(%q) = %v want: %v",
+					test.expr, test.opts, s, got, want)
+			}
+		}
+	}
+}
+
+func TestNewWithOptionsPOSIXDotNL(t *testing.T) {
+	re := NewWithOptions(`^x.y$`, Options{POSIX: true, DotNL: true})
+	if !re.MatchString("x\ny") {
+		t.Error("DotNL: . should match a newline")
+	}
+	if re := NewPOSIX(`^x.y$`); re.MatchString("x\ny") {
+		t.Error("POSIX: . should not match a newline")
+	}
+	// POSIX is leftmost-longest
+	re = NewWithOptions(`a|ab`, Options{POSIX: true, FoldCase: true})
+	if got := re.FindString("AB"); got != "AB" {
+		t.Errorf("FindString: got: %q want: %q", got, "AB")
+	}
+}
+
+func TestNewWithOptionsError(t *testing.T) {
+	tests := []struct {
+		expr string
+		opts Options
+		code syntax.ErrorCode
+	}{
+		{`(a`, Options{FoldCase: true}, syntax.ErrMissingParen},
+		{`(a`, Options{POSIX: true, FoldCase: true}, syntax.ErrMissingParen},
+		// Perl syntax is not allowed in POSIX mode
+		{`\d`, Options{POSIX: true, DotNL: true}, syntax.ErrInvalidEscape},
+	}
+	for _, test := range tests {
+		err := NewWithOptions(test.expr, test.opts).Compile()
+		var serr *syntax.Error
+		if !errors.As(err, &serr) || serr.Code != test.code {
+			t.Errorf("%q %+v: got error: %v want: %v", test.expr, test.opts, err, test.code)
+			continue
+		}
+		// the error should not refer to the rewritten pattern
+		if strings.Contains(err.Error(), "(?") {
+			t.Errorf("%q %+v: error refers to the rewritten pattern: %v", test.expr, test.opts, err)
+		}
+	}
+	// Literal patterns are always valid
+	if err := NewWithOptions(`(a`, Options{Literal: true}).Compile(); err != nil {
+		t.Error(err)
+	}
+}
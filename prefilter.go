@@ -0,0 +1,191 @@
+package reonce
+
+import (
+	"bytes"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxRequiredLiterals is the maximum number of required literals checked by
+// a prefilter. Checking more adds cost to inputs that contain them for
+// little benefit.
+const maxRequiredLiterals = 4
+
+// A prefilter rejects inputs that cannot match a pattern because they do
+// not contain a literal that every match of the pattern contains.
+type prefilter struct {
+	lits  []string
+	blits [][]byte
+}
+
+// newPrefilter returns a prefilter for the syntax tree of the compiled
+// Regexp rx or nil if a prefilter would not reject inputs faster than rx.
+// That is the case if the pattern has no required literals, is anchored at
+// the start of the text or line, where rx fails fast on its own, or if every
+// required literal is part of the literal prefix that rx searches for
+// before running its engine.
+func newPrefilter(tree *syntax.Regexp, rx *regexp.Regexp) *prefilter {
+	if anchoredStart(tree) {
+		return nil
+	}
+	lits := requiredLiterals(tree)
+	prefix, _ := rx.LiteralPrefix()
+	redundant := true
+	for _, lit := range lits {
+		if !strings.Contains(prefix, lit) {
+			redundant = false
+			break
+		}
+	}
+	if redundant {
+		return nil
+	}
+	p := &prefilter{lits: lits, blits: make([][]byte, len(lits))}
+	for i, lit := range lits {
+		p.blits[i] = []byte(lit)
+	}
+	return p
+}
+
+// match reports if b (or s, if b is nil) contains all of the required
+// literals and therefore may match.
+func (p *prefilter) match(b []byte, s string) bool {
+	for i, lit := range p.lits {
+		if b != nil {
+			if !bytes.Contains(b, p.blits[i]) {
+				return false
+			}
+		} else if !strings.Contains(s, lit) {
+			return false
+		}
+	}
+	return true
+}
+
+// anchoredStart reports if every match of the syntax tree re starts at
+// the beginning of the text or of a line.
+func anchoredStart(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpBeginText, syntax.OpBeginLine:
+		return true
+	case syntax.OpCapture:
+		return anchoredStart(re.Sub[0])
+	case syntax.OpConcat:
+		return len(re.Sub) > 0 && anchoredStart(re.Sub[0])
+	}
+	return false
+}
+
+// requiredLiterals returns the literal strings that every match of the
+// syntax tree re contains, longest first. It is conservative and may not
+// find every required literal.
+func requiredLiterals(re *syntax.Regexp) []string {
+	var lits []string
+	for _, lit := range appendRequired(nil, re) {
+		if lit != "" && !slices.Contains(lits, lit) {
+			lits = append(lits, lit)
+		}
+	}
+	slices.SortStableFunc(lits, func(a, b string) int { return len(b) - len(a) })
+	if len(lits) > maxRequiredLiterals {
+		lits = lits[:maxRequiredLiterals]
+	}
+	return lits
+}
+
+// appendRequired appends literals that every match of re contains to lits.
+func appendRequired(lits []string, re *syntax.Regexp) []string {
+	if s, ok := exactLiteral(re); ok {
+		return append(lits, s)
+	}
+	switch re.Op {
+	case syntax.OpConcat:
+		// Join the runs of adjacent subexpressions that match an exact
+		// string, since the text they match is contiguous.
+		var run []byte
+		for _, sub := range re.Sub {
+			if s, ok := exactLiteral(sub); ok {
+				run = append(run, s...)
+				continue
+			}
+			lits = append(lits, string(run))
+			run = run[:0]
+			lits = appendRequired(lits, sub)
+		}
+		lits = append(lits, string(run))
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase == 0 {
+			// Use the text between the utf8.RuneErrors that prevent the
+			// literal from being exact.
+			lits = append(lits, strings.Split(string(re.Rune), string(utf8.RuneError))...)
+		}
+	case syntax.OpCapture, syntax.OpPlus:
+		lits = appendRequired(lits, re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			lits = appendRequired(lits, re.Sub[0])
+		}
+	}
+	return lits
+}
+
+// exactLiteral returns the string matched by re if re always matches the
+// same string, which may be empty for empty-width assertions.
+func exactLiteral(re *syntax.Regexp) (string, bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return "", false
+		}
+		for _, r := range re.Rune {
+			// The regexp package matches invalid UTF-8 in the input as
+			// utf8.RuneError, which a substring search would not.
+			if r == utf8.RuneError {
+				return "", false
+			}
+		}
+		return string(re.Rune), true
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText,
+		syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return "", true
+	case syntax.OpCapture:
+		return exactLiteral(re.Sub[0])
+	case syntax.OpConcat:
+		var b []byte
+		for _, sub := range re.Sub {
+			s, ok := exactLiteral(sub)
+			if !ok {
+				return "", false
+			}
+			b = append(b, s...)
+		}
+		return string(b), true
+	}
+	return "", false
+}
+
+// RequiredLiterals returns the literal strings that the Regexp found every
+// match must contain when it was compiled, longest first. Methods that find
+// matches, such as MatchString and FindStringIndex, check that the input
+// contains these literals before running the regexp engine. A nil return
+// value indicates that no literals are checked, either because none were
+// found or because the regexp package already rejects inputs that lack
+// them quickly, such as when the pattern is anchored at the start of the
+// text or the literals are part of its LiteralPrefix. The slice should not
+// be modified.
+func (re *Regexp) RequiredLiterals() []string {
+	re.re()
+	if re.pre == nil {
+		return nil
+	}
+	return re.pre.lits
+}
+
+// rejects reports if the prefilter of re, if any, shows that b (or s, if b
+// is nil) cannot match.
+func (re *Regexp) rejects(b []byte, s string) bool {
+	return re.pre != nil && !re.pre.match(b, s)
+}
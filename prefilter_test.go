@@ -0,0 +1,85 @@
+//go:build go1.23 && !reoncetest
+
+package reonce
+
+import (
+	"math/rand"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRequiredLiterals(t *testing.T) {
+	tests := []struct {
+		expr string
+		opts Options
+		want []string
+	}{
+		{expr: `error: .* timeout after \d+s`, want: []string{" timeout after ", "error: ", "s"}},
+		{expr: `foo`, want: nil},   // LiteralPrefix
+		{expr: `^foo$`, want: nil}, // anchored
+		{expr: `(ab)c`, want: nil}, // LiteralPrefix
+		{expr: `a\bb`, want: []string{"ab"}},
+		{expr: `x(abc)+y`, want: []string{"abc", "x", "y"}},
+		{expr: `(abc){2,}`, want: nil}, // LiteralPrefix
+		{expr: `\d+abc`, want: []string{"abc"}},
+		{expr: `^abc\d+`, want: nil},
+		{expr: `\Aabc\d+x`, want: nil},
+		{expr: `(?m)^abc\d+x`, want: nil},
+		{expr: `(^abc)\d+x`, want: nil},
+		{expr: `abc\d+x`, want: []string{"abc", "x"}},
+		{expr: `a(?i:b)c`, want: []string{"a", "c"}},
+		{expr: `a|b`, want: nil},
+		{expr: `(abc)*`, want: nil},
+		{expr: `(abc)?`, want: nil},
+		{expr: `(?i)abc`, want: nil},
+		{expr: `\x{FFFD}a`, want: []string{"a"}},
+		{expr: ``, want: nil},
+		{expr: `a.b.c.d.e`, want: []string{"a", "b", "c", "d"}},
+		{expr: `ab|c`, opts: Options{Literal: true}, want: nil},
+		{expr: `.(ab)+`, opts: Options{POSIX: true}, want: []string{"ab"}},
+	}
+	for _, tt := range tests {
+		re := NewWithOptions(tt.expr, tt.opts)
+		if got := re.RequiredLiterals(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: RequiredLiterals() = %q want: %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestRequiredLiteralsNeverMatch(t *testing.T) {
+	re := NewWithOptions(`(`, Options{OnError: NeverMatch(nil)})
+	if lits := re.RequiredLiterals(); lits != nil {
+		t.Errorf("RequiredLiterals() = %q want: nil", lits)
+	}
+}
+
+func TestPrefilterRandom(t *testing.T) {
+	atoms := []string{"a", "b", "ab", "☺", " ", `\b`, "^", "$", "(?m:$)", "a*", "b+", "(ab)",
+		"(a|b)", "(|a)", ".", `\w`, `\z`, "(ab){2}", "(?i:A)", `\x{FFFD}`}
+	const chars = "ab ☺\n�A"
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 2000; i++ {
+		expr, s := randomMatchTest(r, atoms, chars)
+		re := New(expr)
+		rx := regexp.MustCompile(expr)
+		b := []byte(s)
+		check := func(name string, got, want any) {
+			t.Helper()
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("%q (literals %q): %s(%q) = %v want: %v", expr, re.RequiredLiterals(),
+					name, s, got, want)
+			}
+		}
+		check("Match", re.Match(b), rx.Match(b))
+		check("MatchString", re.MatchString(s), rx.MatchString(s))
+		check("FindStringIndex", re.FindStringIndex(s), rx.FindStringIndex(s))
+		check("FindSubmatchIndex", re.FindSubmatchIndex(b), rx.FindSubmatchIndex(b))
+		check("FindAllString", re.FindAllString(s, -1), rx.FindAllString(s, -1))
+		check("FindAllSubmatch", re.FindAllSubmatch(b, -1), rx.FindAllSubmatch(b, -1))
+		check("FindAllStringSubmatchIndex", re.FindAllStringSubmatchIndex(s, -1),
+			rx.FindAllStringSubmatchIndex(s, -1))
+	}
+}
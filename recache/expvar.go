@@ -0,0 +1,24 @@
+package recache
+
+import (
+	"expvar"
+	"sync"
+)
+
+var publishOnce sync.Once
+
+// Publish publishes the statistics of the default caches as the expvar
+// variable "recache", which is a JSON object with the CacheStats of the
+// "std" and "posix" caches. The statistics are computed when the variable
+// is read. Importing the package does not publish anything and it is safe
+// to call Publish more than once.
+func Publish() {
+	publishOnce.Do(func() {
+		expvar.Publish("recache", expvar.Func(func() any {
+			return map[string]CacheStats{
+				"std":   Stats(),
+				"posix": StatsPOSIX(),
+			}
+		}))
+	})
+}
@@ -36,6 +36,15 @@ func (l *list) Back() *entry {
 	return nil
 }
 
+// Next returns the Element after e in list l or nil if e is the last
+// Element. The Element must not be nil.
+func (l *list) Next(e *entry) *entry {
+	if e.next == &l.root {
+		return nil
+	}
+	return e.next
+}
+
 // insert inserts e after at, increments l.len, and returns e.
 func (l *list) insert(e, at *entry) *entry {
 	e.prev = at
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/charlievieth/reonce"
+	"github.com/charlievieth/reonce/internal/unregistered"
 )
 
 type entry struct {
@@ -24,6 +25,11 @@ type Cache struct {
 	ll         *list
 	maxEntries int // zero means no limit
 	posix      bool
+
+	// counters reported by Stats
+	hits      uint64
+	misses    uint64
+	evictions uint64
 }
 
 func newCache(maxEntries int, posix bool) *Cache {
@@ -81,16 +87,14 @@ func (c *Cache) get(expr string) *reonce.Regexp {
 	c.mu.Lock()
 	ee := c.cache[expr]
 	if ee != nil {
+		c.hits++
 		c.ll.MoveToFront(ee)
 	} else {
+		c.misses++
 		if c.cache == nil {
 			c.lazyInit()
 		}
-		if !c.posix {
-			ee = &entry{re: reonce.New(expr)}
-		} else {
-			ee = &entry{re: reonce.NewPOSIX(expr)}
-		}
+		ee = &entry{re: newRegexp(expr, c.posix)}
 		if c.maxEntries != 0 && c.ll.Len() >= c.maxEntries {
 			c.removeOldest()
 		}
@@ -100,6 +104,13 @@ func (c *Cache) get(expr string) *reonce.Regexp {
 	return ee.re
 }
 
+// newRegexp returns a lazily initialized Regexp that is not recorded in the
+// reonce registry since cached Regexps are created dynamically and may be
+// evicted.
+func newRegexp(expr string, posix bool) *reonce.Regexp {
+	return unregistered.New(expr, posix).(*reonce.Regexp)
+}
+
 // Compile compiles the Regexp and panics if there is an error.
 // If the Regexp has already been compiled the cached Regexp is returned.
 // Otherwise the Regexp is compiled and added to the Cache.
@@ -114,7 +125,9 @@ func (c *Cache) Compile(key string) (*regexp.Regexp, error) {
 // MustCompile compiles the Regexp and panics if there is an error.
 // If the Regexp has already been compiled the cached Regexp is returned.
 func (c *Cache) MustCompile(key string) *regexp.Regexp {
-	return c.get(key).Regexp()
+	re := c.get(key)
+	re.MustCompile()
+	return re.Regexp()
 }
 
 // removeOldest removes the oldest item from the cache.
@@ -122,6 +135,7 @@ func (c *Cache) removeOldest() {
 	ele := c.ll.Back()
 	if ele != nil {
 		c.removeElement(ele)
+		c.evictions++
 	}
 }
 
@@ -130,6 +144,29 @@ func (c *Cache) removeElement(e *entry) {
 	delete(c.cache, e.re.String())
 }
 
+// CacheStats are the statistics of a Cache.
+type CacheStats struct {
+	Len        int    // number of cached Regexps
+	MaxEntries int    // maximum number of cached Regexps, zero means no limit
+	Hits       uint64 // lookups that found a cached Regexp
+	Misses     uint64 // lookups that added a Regexp to the cache
+	Evictions  uint64 // Regexps removed to make room for new ones
+}
+
+// Stats returns the statistics of the Cache.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	s := CacheStats{
+		Len:        len(c.cache),
+		MaxEntries: c.maxEntries,
+		Hits:       c.hits,
+		Misses:     c.misses,
+		Evictions:  c.evictions,
+	}
+	c.mu.Unlock()
+	return s
+}
+
 // Len returns the number of items in the cache.
 func (c *Cache) Len() int {
 	c.mu.Lock()
@@ -138,6 +175,21 @@ func (c *Cache) Len() int {
 	return n
 }
 
+// Keys returns the patterns of the cached Regexps ordered from the most to
+// the least recently used.
+func (c *Cache) Keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ll == nil {
+		return nil
+	}
+	keys := make([]string, 0, c.ll.Len())
+	for e := c.ll.Front(); e != nil; e = c.ll.Next(e) {
+		keys = append(keys, e.re.String())
+	}
+	return keys
+}
+
 // DefaultCacheSize is the size of default Cache.
 const DefaultCacheSize = 256
 
@@ -199,3 +251,9 @@ func SetMaxEntriesPOSIX(n int) (prev int) {
 
 // LenPOSIX returns the number of cached Regexps in the default POSIX Cache.
 func LenPOSIX() int { return posix.Len() }
+
+// Stats returns the statistics of the default Cache.
+func Stats() CacheStats { return std.Stats() }
+
+// StatsPOSIX returns the statistics of the default POSIX Cache.
+func StatsPOSIX() CacheStats { return posix.Stats() }
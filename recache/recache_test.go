@@ -1,12 +1,17 @@
 package recache
 
 import (
+	"encoding/json"
+	"expvar"
 	"fmt"
+	"reflect"
 	"regexp"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"testing"
+
+	"github.com/charlievieth/reonce"
 )
 
 func TestDefaultCacheValues(t *testing.T) {
@@ -354,3 +359,120 @@ func BenchmarkCompile_Parallel(b *testing.B) {
 		}
 	})
 }
+
+func TestCacheNotRegistered(t *testing.T) {
+	c := New(0)
+	re := c.get("recache-unregistered")
+	reonce.Walk(func(r *reonce.Regexp) bool {
+		if r == re {
+			t.Error("cached Regexp was added to the reonce registry")
+		}
+		return true
+	})
+}
+
+func TestCacheStats(t *testing.T) {
+	c := New(2)
+	c.MustCompile("a") // miss
+	c.MustCompile("a") // hit
+	c.MustCompile("b") // miss
+	c.MustCompile("c") // miss, evicts "a"
+	c.MustCompile("b") // hit
+	want := CacheStats{Len: 2, MaxEntries: 2, Hits: 2, Misses: 3, Evictions: 1}
+	if got := c.Stats(); got != want {
+		t.Errorf("Stats() = %+v; want: %+v", got, want)
+	}
+	c.SetMaxEntries(1) // evicts "c"
+	want = CacheStats{Len: 1, MaxEntries: 1, Hits: 2, Misses: 3, Evictions: 2}
+	if got := c.Stats(); got != want {
+		t.Errorf("Stats() = %+v; want: %+v", got, want)
+	}
+
+	var zero Cache
+	if got := zero.Stats(); got != (CacheStats{}) {
+		t.Errorf("zero Cache: Stats() = %+v; want: %+v", got, CacheStats{})
+	}
+}
+
+// published is set once TestPublish has called Publish, expvar variables
+// cannot be removed so they remain published when tests are repeated.
+var published bool
+
+func TestPublish(t *testing.T) {
+	if v := expvar.Get("recache"); v != nil && !published {
+		t.Fatalf("recache published before calling Publish: %s", v)
+	}
+	published = true
+	Publish()
+	Publish() // no-op
+	MustCompile("test-publish")
+	MustCompile("test-publish")
+
+	var vars map[string]CacheStats
+	if err := json.Unmarshal([]byte(expvar.Get("recache").String()), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := vars["std"], Stats(); got != want {
+		t.Errorf("std = %+v; want: %+v", got, want)
+	}
+	if got, want := vars["posix"], StatsPOSIX(); got != want {
+		t.Errorf("posix = %+v; want: %+v", got, want)
+	}
+	if vars["std"].Hits == 0 || vars["std"].Misses == 0 {
+		t.Errorf("std: expected hits and misses: %+v", vars["std"])
+	}
+}
+
+func TestCacheKeys(t *testing.T) {
+	var zero Cache
+	if keys := zero.Keys(); keys != nil {
+		t.Errorf("zero Cache: Keys() = %q; want: nil", keys)
+	}
+
+	c := New(3)
+	for _, s := range []string{"a", "b", "c", "a", "d"} {
+		c.MustCompile(s)
+	}
+	want := []string{"d", "a", "c"}
+	if got := c.Keys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %q; want: %q", got, want)
+	}
+}
+
+// registerCount makes the names registered by TestRegister unique since
+// Caches cannot be unregistered and tests may be repeated.
+var registerCount atomic.Int64
+
+func TestRegister(t *testing.T) {
+	name := t.Name() + "-" + strconv.FormatInt(registerCount.Add(1), 10)
+	c := New(1)
+	Register(name, c)
+
+	names := make(map[string]*Cache)
+	Walk(func(name string, c *Cache) bool {
+		names[name] = c
+		return true
+	})
+	want := map[string]*Cache{"std": std, "posix": posix, name: c}
+	for name, c := range want {
+		if names[name] != c {
+			t.Errorf("Walk: %q = %p; want: %p", name, names[name], c)
+		}
+	}
+
+	n := 0
+	Walk(func(string, *Cache) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("Walk did not stop: called %d times", n)
+	}
+
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("Register should panic if the name is already registered")
+		}
+	}()
+	Register(name, New(1))
+}
@@ -0,0 +1,49 @@
+package recache
+
+import (
+	"strconv"
+	"sync"
+)
+
+type namedCache struct {
+	name  string
+	cache *Cache
+}
+
+var registry struct {
+	mu   sync.Mutex
+	list []namedCache
+}
+
+// The default caches are registered as "std" and "posix".
+func init() {
+	Register("std", std)
+	Register("posix", posix)
+}
+
+// Register records the Cache c under name so that it is visited by Walk,
+// which allows debugging tools to inspect it. Register panics if name is
+// already registered. Caches that are registered are never released.
+func Register(name string, c *Cache) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for _, nc := range registry.list {
+		if nc.name == name {
+			panic("recache: Register called twice for name " + strconv.Quote(name))
+		}
+	}
+	registry.list = append(registry.list, namedCache{name: name, cache: c})
+}
+
+// Walk calls fn for every registered Cache, in the order they were
+// registered, until fn returns false. It is safe for fn to call Register.
+func Walk(fn func(name string, c *Cache) bool) {
+	registry.mu.Lock()
+	list := registry.list[:len(registry.list):len(registry.list)]
+	registry.mu.Unlock()
+	for _, nc := range list {
+		if !fn(nc.name, nc.cache) {
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package reonce
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/charlievieth/reonce/internal/unregistered"
+)
+
+// registry records every Regexp created by New, NewPOSIX, NewWithOptions
+// and NewSet. Regexps are only ever appended, and unregister copies the
+// list, so a snapshot of the list can be read without holding the lock.
+var registry struct {
+	mu   sync.Mutex
+	list []*Regexp
+}
+
+func init() {
+	// Used by recache, which creates Regexps dynamically and must not
+	// leak them into the registry.
+	unregistered.New = func(expr string, posix bool) any {
+		return &Regexp{expr: expr, opts: Options{POSIX: posix}}
+	}
+}
+
+func register(re *Regexp) {
+	registry.mu.Lock()
+	re.inReg = true
+	registry.list = append(registry.list, re)
+	registry.mu.Unlock()
+}
+
+// unregister removes re from the registry, if present. The list is copied
+// so that snapshots returned by registered are not modified.
+func unregister(re *Regexp) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if !re.inReg {
+		return
+	}
+	re.inReg = false
+	i := slices.Index(registry.list, re)
+	if i < 0 {
+		return
+	}
+	list := make([]*Regexp, 0, len(registry.list)-1)
+	list = append(list, registry.list[:i]...)
+	registry.list = append(list, registry.list[i+1:]...)
+}
+
+// registered returns a snapshot of the registry, the returned slice must
+// not be modified.
+func registered() []*Regexp {
+	registry.mu.Lock()
+	list := registry.list[:len(registry.list):len(registry.list)]
+	registry.mu.Unlock()
+	return list
+}
+
+// Registered returns every registered Regexp in the order they were
+// created. See Walk.
+func Registered() []*Regexp {
+	list := registered()
+	return append(make([]*Regexp, 0, len(list)), list...)
+}
+
+// Walk calls fn for every registered Regexp, in the order they were
+// created, until fn returns false. Regexps created while Walk is running
+// may not be visited. It is safe for fn to call New or NewPOSIX.
+//
+// The Regexps created by New, NewPOSIX, NewWithOptions and the patterns of
+// a Set created by NewSet are registered. Regexps created by the flag
+// functions, such as Flag and RegexpList, by the recache package, or by
+// decoding into a zero Regexp are not.
+//
+// Walk does not compile any Regexps, use the Compiled and Err methods to
+// inspect their state.
+func Walk(fn func(re *Regexp) bool) {
+	for _, re := range registered() {
+		if !fn(re) {
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"testing"
+
+	"github.com/charlievieth/reonce/internal/unregistered"
+)
+
+func isRegistered(re *Regexp) bool {
+	found := false
+	Walk(func(r *Regexp) bool {
+		found = r == re
+		return !found
+	})
+	return found
+}
+
+func TestRegistry(t *testing.T) {
+	re1 := New("a")
+	re2 := NewPOSIX("b")
+	re3 := New("*")
+
+	list := Registered()
+	if len(list) < 3 {
+		t.Fatalf("Registered: got %d Regexps want at least 3", len(list))
+	}
+	if got := list[len(list)-3:]; got[0] != re1 || got[1] != re2 || got[2] != re3 {
+		t.Fatal("Registered: Regexps not returned in creation order")
+	}
+	for _, re := range []*Regexp{re1, re2, re3} {
+		if !isRegistered(re) {
+			t.Errorf("Walk: did not visit %q", re.String())
+		}
+		if re.Compiled() {
+			t.Errorf("%q: Compiled should be false before first use", re.String())
+		}
+		if err := re.Err(); err != nil {
+			t.Errorf("%q: Err should be nil before first use: %v", re.String(), err)
+		}
+	}
+	if re1.POSIX() || !re2.POSIX() {
+		t.Errorf("POSIX: got: %t, %t want: false, true", re1.POSIX(), re2.POSIX())
+	}
+
+	re1.MustCompile()
+	if !re1.Compiled() || re1.Err() != nil {
+		t.Errorf("%q: Compiled: %t Err: %v", re1.String(), re1.Compiled(), re1.Err())
+	}
+	err := re3.Compile()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !re3.Compiled() || re3.Err() != err {
+		t.Errorf("%q: Compiled: %t Err: %v want: %v", re3.String(), re3.Compiled(), re3.Err(), err)
+	}
+}
+
+func TestRegistryWalkStop(t *testing.T) {
+	New("a")
+	New("b")
+	n := 0
+	Walk(func(*Regexp) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("Walk: visited %d Regexps after returning false", n)
+	}
+}
+
+func TestRegistryUnregistered(t *testing.T) {
+	re := unregistered.New("a", true).(*Regexp)
+	if isRegistered(re) {
+		t.Error("unregistered Regexp was added to the registry")
+	}
+	if !re.POSIX() {
+		t.Error("unregistered Regexp should be POSIX")
+	}
+	if !re.MatchString("a") {
+		t.Error("failed to match string")
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	a, b, c := New("a"), New("b"), New("c")
+	withRegistry(t, a, b, c)
+	snapshot := registered()
+
+	unregister(b)
+	if got := registered(); len(got) != 2 || got[0] != a || got[1] != c {
+		t.Errorf("registered() = %v; want: [a c]", got)
+	}
+	if snapshot[1] != b {
+		t.Error("unregister modified a snapshot of the registry")
+	}
+	unregister(b) // no-op
+	if got := registered(); len(got) != 2 {
+		t.Errorf("len(registered()) = %d; want: 2", len(got))
+	}
+}
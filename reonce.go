@@ -3,56 +3,166 @@
 package reonce
 
 import (
+	"bytes"
 	"io"
 	"regexp"
+	"runtime"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Regexp is a lazily initialized regexp.Regexp. A Regexp is safe for concurrent
 // use by multiple goroutines, except for configuration methods, such as Longest.
+// Use NewWithOptions to configure a Regexp when it is declared.
 type Regexp struct {
-	rx    *regexp.Regexp
-	once  sync.Once
-	posix bool   // pack this after once to save space
-	expr  string // as passed to Compile
-	err   error  // Compile error, if any
+	rx       *regexp.Regexp
+	once     sync.Once
+	opts     Options
+	compiled atomic.Bool // set once init has run
+	expr     string      // as passed to Compile
+	err      error       // Compile error (*CompileError), if any
+	pc       uintptr     // PC of the caller of New, zero if unknown
+	inReg    bool        // in the registry, guarded by registry.mu
+
+	offset atomic.Pointer[offsetMatcher] // lazily created by offsetMatcher
+	lit    *literalMatcher               // set by init if the pattern is a literal
+	pre    *prefilter                    // set by init if the pattern has required literals
+
+	compiledAt  int64         // UnixNano, set by init
+	compileTime time.Duration // set by init
+	stats       atomic.Pointer[regexpStats]
+}
+
+// callerPC returns the program counter of the caller of the function
+// calling callerPC (the caller of New, NewPOSIX, etc.). The PC is resolved
+// to a file and line lazily since that is comparatively expensive.
+func callerPC() uintptr {
+	var pc [1]uintptr
+	if runtime.Callers(3, pc[:]) != 1 {
+		return 0
+	}
+	return pc[0]
+}
+
+func newRegexp(expr string, opts Options, pc uintptr) *Regexp {
+	re := &Regexp{expr: expr, opts: opts, pc: pc}
+	register(re)
+	re.applyMode(GetMode())
+	return re
 }
 
 // New returns a new lazily initialized Regexp. The underlying *regexp.Regexp
 // will be compiled on first use. If pattern expr is invalid it will panic.
+//
+// The returned Regexp is recorded in a package level registry (see Walk)
+// and is never freed, so New should be used for Regexps that are declared
+// once, such as global variables. Use the recache package for patterns that
+// are created dynamically.
 func New(expr string) *Regexp {
-	re := &Regexp{expr: expr, posix: false}
-	if mustCompile {
-		re.re()
-	}
-	return re
+	return newRegexp(expr, Options{}, callerPC())
 }
 
 // New returns a new lazily initialized POSIX Regexp.
 func NewPOSIX(expr string) *Regexp {
-	re := &Regexp{expr: expr, posix: true}
-	if mustCompile {
-		re.re()
-	}
-	return re
+	return newRegexp(expr, Options{POSIX: true}, callerPC())
+}
+
+// NewWithOptions returns a new lazily initialized Regexp that is compiled
+// using opts. Like New, the Regexp is compiled on first use and recorded in
+// the registry.
+func NewWithOptions(expr string, opts Options) *Regexp {
+	return newRegexp(expr, opts, callerPC())
 }
 
 func (re *Regexp) init() {
-	if re.posix {
-		re.rx, re.err = regexp.CompilePOSIX(re.expr)
+	h := re.hooks()
+	var done func()
+	if h != nil && h.OnFirstUse != nil {
+		done = h.OnFirstUse(re.compileEvent())
+	}
+	start := time.Now()
+	re.rx, re.err = re.opts.compile(re.expr)
+	if re.err != nil {
+		re.err = re.compileError(re.err)
 	} else {
-		re.rx, re.err = regexp.Compile(re.expr)
+		tree, _ := parseCompiled(re.rx, &re.opts)
+		re.lit = newLiteralMatcher(tree)
+		re.pre = newPrefilter(tree, re.rx)
+	}
+	re.compileTime = time.Since(start)
+	re.compiledAt = start.UnixNano()
+	re.compiled.Store(true)
+	if done != nil {
+		done()
+	}
+	if h != nil {
+		re.runHooks(h)
 	}
 }
 
+// runHooks calls the OnCompile or OnError hook of h with the result of
+// compiling re.
+func (re *Regexp) runHooks(h *Hooks) {
+	fn := h.OnCompile
+	if re.err != nil {
+		fn = h.OnError
+	}
+	if fn == nil {
+		return
+	}
+	ev := re.compileEvent()
+	ev.Duration = re.compileTime
+	ev.Err = re.err
+	fn(ev)
+}
+
 // Compile manually compiles the Regexp and returns the error, this is a no-op
 // if the Regexp was already lazily compiled by a call to any of it's methods.
+// The returned error, if any, is a *CompileError.
 func (re *Regexp) Compile() error {
 	re.once.Do(re.init)
 	return re.err
 }
 
+// POSIX reports if the Regexp uses POSIX ERE syntax and leftmost-longest
+// matching (it was created with NewPOSIX or Options.POSIX).
+func (re *Regexp) POSIX() bool { return re.opts.POSIX }
+
+// Compiled reports if the Regexp has been compiled. It does not trigger
+// compilation. A Regexp that failed to compile is considered compiled,
+// use Err to check for an error.
+func (re *Regexp) Compiled() bool { return re.compiled.Load() }
+
+// Err returns the error, if any, that occurred when compiling the Regexp.
+// Unlike Compile, Err does not trigger compilation and returns nil if the
+// Regexp has not been compiled yet.
+func (re *Regexp) Err() error {
+	if !re.compiled.Load() {
+		return nil
+	}
+	return re.err
+}
+
+// Caller returns the file name and line number where the Regexp was
+// declared, that is the location of the call to New or one of its
+// variants, such as NewPOSIX, NewWithOptions, NewSet or Flag. The
+// location is included in compile errors and panics. The boolean ok is
+// false if the location is not known, such as for Regexps created by the
+// recache package.
+func (re *Regexp) Caller() (file string, line int, ok bool) {
+	if re.pc == 0 {
+		return "", 0, false
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{re.pc}).Next()
+	if frame.File == "" {
+		return "", 0, false
+	}
+	return frame.File, frame.Line, true
+}
+
 func quote(s string) string {
 	if strconv.CanBackquote(s) {
 		return "`" + s + "`"
@@ -60,29 +170,67 @@ func quote(s string) string {
 	return strconv.Quote(s)
 }
 
-func (re *Regexp) panicOnErr() {
+// A CompileError is returned by Compile when a Regexp fails to compile and
+// is the value methods that cannot return an error panic with.
+type CompileError struct {
+	Expr  string // the pattern, as passed to New or one of its variants
+	POSIX bool   // the pattern uses POSIX syntax
+	File  string // file where the Regexp was declared, empty if unknown
+	Line  int    // line where the Regexp was declared
+	Err   error  // underlying error, a *syntax.Error
+}
+
+// Error returns the same message regexp.MustCompile and
+// regexp.MustCompilePOSIX panic with followed by the declaration site of
+// the Regexp, if known.
+func (e *CompileError) Error() string {
 	var prefix string
-	if re.posix {
+	if e.POSIX {
 		prefix = `regexp: CompilePOSIX(`
 	} else {
-		prefix = `regexp: POSIX(`
+		prefix = `regexp: Compile(`
+	}
+	msg := prefix + quote(e.Expr) + `): ` + e.Err.Error()
+	if e.File != "" {
+		msg += " (declared at " + e.File + ":" + strconv.Itoa(e.Line) + ")"
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *CompileError) Unwrap() error { return e.Err }
+
+func (re *Regexp) compileError(err error) *CompileError {
+	file, line, _ := re.Caller()
+	return &CompileError{
+		Expr:  re.expr,
+		POSIX: re.opts.POSIX,
+		File:  file,
+		Line:  line,
+		Err:   err,
 	}
-	panic(prefix + quote(re.expr) + `): ` + re.err.Error())
 }
 
-// re compiles and returns the regexp.Regexp if there is an error re panics.
+// re compiles and returns the regexp.Regexp. If there is an error the
+// ErrorHandler of re is called, which panics with the *CompileError by
+// default.
 func (re *Regexp) re() *regexp.Regexp {
 	re.once.Do(re.init)
 	if re.err != nil {
-		re.panicOnErr()
+		return re.handleError()
 	}
 	return re.rx
 }
 
 // MustCompile compiles the Regexp and panics if there is an error, this is a
 // no-op if the Regexp was already lazily compiled by a call to any of  it's
-// methods.
-func (re *Regexp) MustCompile() { re.re() }
+// methods. MustCompile always panics on error and does not use the
+// ErrorHandler of the Regexp.
+func (re *Regexp) MustCompile() {
+	if err := re.Compile(); err != nil {
+		panic(err)
+	}
+}
 
 // Regexp returns the underlying *regexp.Regexp.
 func (re *Regexp) Regexp() *regexp.Regexp {
@@ -119,20 +267,41 @@ func (re *Regexp) Copy() *regexp.Regexp {
 //
 // To insert a literal $ in the output, use $$ in the template.
 func (re *Regexp) Expand(dst []byte, template []byte, src []byte, match []int) []byte {
-	return re.re().Expand(dst, template, src, match)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyReplace)()
+	}
+	return rx.Expand(dst, template, src, match)
 }
 
 // ExpandString is like Expand but the template and source are strings.
 // It appends to and returns a byte slice in order to give the calling
 // code control over allocation.
 func (re *Regexp) ExpandString(dst []byte, template string, src string, match []int) []byte {
-	return re.re().ExpandString(dst, template, src, match)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyReplace)()
+	}
+	return rx.ExpandString(dst, template, src, match)
 }
 
 // Find returns a slice holding the text of the leftmost match in b of the regular expression.
 // A return value of nil indicates no match.
 func (re *Regexp) Find(b []byte) []byte {
-	return re.re().Find(b)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.lit != nil {
+		if a := re.lit.find(b, ""); a != nil {
+			return b[a[0]:a[1]:a[1]]
+		}
+		return nil
+	}
+	if re.rejects(b, "") {
+		return nil
+	}
+	return rx.Find(b)
 }
 
 // FindAll is the 'All' version of Find; it returns a slice of all successive
@@ -140,7 +309,21 @@ func (re *Regexp) Find(b []byte) []byte {
 // package comment.
 // A return value of nil indicates no match.
 func (re *Regexp) FindAll(b []byte, n int) [][]byte {
-	return re.re().FindAll(b, n)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.lit != nil {
+		var matches [][]byte
+		for _, a := range re.lit.findAll(nonNil(b), "", n) {
+			matches = append(matches, b[a[0]:a[1]:a[1]])
+		}
+		return matches
+	}
+	if re.rejects(b, "") {
+		return nil
+	}
+	return rx.FindAll(b, n)
 }
 
 // FindAllIndex is the 'All' version of FindIndex; it returns a slice of all
@@ -148,7 +331,17 @@ func (re *Regexp) FindAll(b []byte, n int) [][]byte {
 // in the package comment.
 // A return value of nil indicates no match.
 func (re *Regexp) FindAllIndex(b []byte, n int) [][]int {
-	return re.re().FindAllIndex(b, n)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.lit != nil {
+		return re.lit.findAll(nonNil(b), "", n)
+	}
+	if re.rejects(b, "") {
+		return nil
+	}
+	return rx.FindAllIndex(b, n)
 }
 
 // FindAllString is the 'All' version of FindString; it returns a slice of all
@@ -156,7 +349,21 @@ func (re *Regexp) FindAllIndex(b []byte, n int) [][]int {
 // in the package comment.
 // A return value of nil indicates no match.
 func (re *Regexp) FindAllString(s string, n int) []string {
-	return re.re().FindAllString(s, n)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.lit != nil {
+		var matches []string
+		for _, a := range re.lit.findAll(nil, s, n) {
+			matches = append(matches, s[a[0]:a[1]])
+		}
+		return matches
+	}
+	if re.rejects(nil, s) {
+		return nil
+	}
+	return rx.FindAllString(s, n)
 }
 
 // FindAllStringIndex is the 'All' version of FindStringIndex; it returns a
@@ -164,7 +371,17 @@ func (re *Regexp) FindAllString(s string, n int) []string {
 // description in the package comment.
 // A return value of nil indicates no match.
 func (re *Regexp) FindAllStringIndex(s string, n int) [][]int {
-	return re.re().FindAllStringIndex(s, n)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.lit != nil {
+		return re.lit.findAll(nil, s, n)
+	}
+	if re.rejects(nil, s) {
+		return nil
+	}
+	return rx.FindAllStringIndex(s, n)
 }
 
 // FindAllStringSubmatch is the 'All' version of FindStringSubmatch; it
@@ -172,7 +389,14 @@ func (re *Regexp) FindAllStringIndex(s string, n int) [][]int {
 // the 'All' description in the package comment.
 // A return value of nil indicates no match.
 func (re *Regexp) FindAllStringSubmatch(s string, n int) [][]string {
-	return re.re().FindAllStringSubmatch(s, n)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.rejects(nil, s) {
+		return nil
+	}
+	return rx.FindAllStringSubmatch(s, n)
 }
 
 // FindAllStringSubmatchIndex is the 'All' version of
@@ -181,7 +405,14 @@ func (re *Regexp) FindAllStringSubmatch(s string, n int) [][]string {
 // comment.
 // A return value of nil indicates no match.
 func (re *Regexp) FindAllStringSubmatchIndex(s string, n int) [][]int {
-	return re.re().FindAllStringSubmatchIndex(s, n)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.rejects(nil, s) {
+		return nil
+	}
+	return rx.FindAllStringSubmatchIndex(s, n)
 }
 
 // FindAllSubmatch is the 'All' version of FindSubmatch; it returns a slice
@@ -189,7 +420,14 @@ func (re *Regexp) FindAllStringSubmatchIndex(s string, n int) [][]int {
 // description in the package comment.
 // A return value of nil indicates no match.
 func (re *Regexp) FindAllSubmatch(b []byte, n int) [][][]byte {
-	return re.re().FindAllSubmatch(b, n)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.rejects(b, "") {
+		return nil
+	}
+	return rx.FindAllSubmatch(b, n)
 }
 
 // FindAllSubmatchIndex is the 'All' version of FindSubmatchIndex; it returns
@@ -197,7 +435,14 @@ func (re *Regexp) FindAllSubmatch(b []byte, n int) [][][]byte {
 // 'All' description in the package comment.
 // A return value of nil indicates no match.
 func (re *Regexp) FindAllSubmatchIndex(b []byte, n int) [][]int {
-	return re.re().FindAllSubmatchIndex(b, n)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.rejects(b, "") {
+		return nil
+	}
+	return rx.FindAllSubmatchIndex(b, n)
 }
 
 // FindIndex returns a two-element slice of integers defining the location of
@@ -205,7 +450,17 @@ func (re *Regexp) FindAllSubmatchIndex(b []byte, n int) [][]int {
 // b[loc[0]:loc[1]].
 // A return value of nil indicates no match.
 func (re *Regexp) FindIndex(b []byte) (loc []int) {
-	return re.re().FindIndex(b)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.lit != nil {
+		return re.lit.find(nonNil(b), "")
+	}
+	if re.rejects(b, "") {
+		return nil
+	}
+	return rx.FindIndex(b)
 }
 
 // FindReaderIndex returns a two-element slice of integers defining the
@@ -214,7 +469,11 @@ func (re *Regexp) FindIndex(b []byte) (loc []int) {
 // byte offset loc[0] through loc[1]-1.
 // A return value of nil indicates no match.
 func (re *Regexp) FindReaderIndex(r io.RuneReader) (loc []int) {
-	return re.re().FindReaderIndex(r)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	return rx.FindReaderIndex(r)
 }
 
 // FindReaderSubmatchIndex returns a slice holding the index pairs
@@ -223,7 +482,11 @@ func (re *Regexp) FindReaderIndex(r io.RuneReader) (loc []int) {
 // by the 'Submatch' and 'Index' descriptions in the package comment. A
 // return value of nil indicates no match.
 func (re *Regexp) FindReaderSubmatchIndex(r io.RuneReader) []int {
-	return re.re().FindReaderSubmatchIndex(r)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	return rx.FindReaderSubmatchIndex(r)
 }
 
 // FindString returns a string holding the text of the leftmost match in s of the regular
@@ -232,7 +495,20 @@ func (re *Regexp) FindReaderSubmatchIndex(r io.RuneReader) []int {
 // an empty string. Use FindStringIndex or FindStringSubmatch if it is
 // necessary to distinguish these cases.
 func (re *Regexp) FindString(s string) string {
-	return re.re().FindString(s)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.lit != nil {
+		if a := re.lit.find(nil, s); a != nil {
+			return s[a[0]:a[1]]
+		}
+		return ""
+	}
+	if re.rejects(nil, s) {
+		return ""
+	}
+	return rx.FindString(s)
 }
 
 // FindStringIndex returns a two-element slice of integers defining the
@@ -240,7 +516,17 @@ func (re *Regexp) FindString(s string) string {
 // itself is at s[loc[0]:loc[1]].
 // A return value of nil indicates no match.
 func (re *Regexp) FindStringIndex(s string) (loc []int) {
-	return re.re().FindStringIndex(s)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.lit != nil {
+		return re.lit.find(nil, s)
+	}
+	if re.rejects(nil, s) {
+		return nil
+	}
+	return rx.FindStringIndex(s)
 }
 
 // FindStringSubmatch returns a slice of strings holding the text of the
@@ -249,7 +535,14 @@ func (re *Regexp) FindStringIndex(s string) (loc []int) {
 // package comment.
 // A return value of nil indicates no match.
 func (re *Regexp) FindStringSubmatch(s string) []string {
-	return re.re().FindStringSubmatch(s)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.rejects(nil, s) {
+		return nil
+	}
+	return rx.FindStringSubmatch(s)
 }
 
 // FindStringSubmatchIndex returns a slice holding the index pairs
@@ -258,7 +551,14 @@ func (re *Regexp) FindStringSubmatch(s string) []string {
 // 'Index' descriptions in the package comment.
 // A return value of nil indicates no match.
 func (re *Regexp) FindStringSubmatchIndex(s string) []int {
-	return re.re().FindStringSubmatchIndex(s)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.rejects(nil, s) {
+		return nil
+	}
+	return rx.FindStringSubmatchIndex(s)
 }
 
 // FindSubmatch returns a slice of slices holding the text of the leftmost
@@ -267,7 +567,14 @@ func (re *Regexp) FindStringSubmatchIndex(s string) []int {
 // comment.
 // A return value of nil indicates no match.
 func (re *Regexp) FindSubmatch(b []byte) [][]byte {
-	return re.re().FindSubmatch(b)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.rejects(b, "") {
+		return nil
+	}
+	return rx.FindSubmatch(b)
 }
 
 // FindSubmatchIndex returns a slice holding the index pairs identifying the
@@ -276,7 +583,14 @@ func (re *Regexp) FindSubmatch(b []byte) [][]byte {
 // in the package comment.
 // A return value of nil indicates no match.
 func (re *Regexp) FindSubmatchIndex(b []byte) []int {
-	return re.re().FindSubmatchIndex(b)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	if re.rejects(b, "") {
+		return nil
+	}
+	return rx.FindSubmatchIndex(b)
 }
 
 // LiteralPrefix returns a literal string that must begin any match
@@ -291,27 +605,62 @@ func (re *Regexp) LiteralPrefix() (prefix string, complete bool) {
 // begins as early as possible in the input (leftmost), and among those
 // it chooses a match that is as long as possible.
 // This method modifies the Regexp and may not be called concurrently
-// with any other methods.
+// with any other methods. Use Options.Longest to configure leftmost-longest
+// matching in a way that is safe for concurrent use.
 func (re *Regexp) Longest() {
-	re.re().Longest()
+	re.setLongest(re.re())
+}
+
+// setLongest makes rx, the compiled Regexp of re, leftmost-longest.
+func (re *Regexp) setLongest(rx *regexp.Regexp) {
+	if re.err != nil {
+		return // rx is the shared Regexp that never matches
+	}
+	rx.Longest()
+	re.opts.Longest = true
+	re.offset.Store(nil)
 }
 
 // Match reports whether the byte slice b
 // contains any match of the regular expression re.
 func (re *Regexp) Match(b []byte) bool {
-	return re.re().Match(b)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyMatch)()
+	}
+	if re.lit != nil {
+		return re.lit.index(nonNil(b), "", 0) >= 0
+	}
+	if re.rejects(b, "") {
+		return false
+	}
+	return rx.Match(b)
 }
 
 // MatchReader reports whether the text returned by the RuneReader
 // contains any match of the regular expression re.
 func (re *Regexp) MatchReader(r io.RuneReader) bool {
-	return re.re().MatchReader(r)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyMatch)()
+	}
+	return rx.MatchReader(r)
 }
 
 // MatchString reports whether the string s
 // contains any match of the regular expression re.
 func (re *Regexp) MatchString(s string) bool {
-	return re.re().MatchString(s)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyMatch)()
+	}
+	if re.lit != nil {
+		return re.lit.index(nil, s, 0) >= 0
+	}
+	if re.rejects(nil, s) {
+		return false
+	}
+	return rx.MatchString(s)
 }
 
 // NumSubexp returns the number of parenthesized subexpressions in this Regexp.
@@ -323,7 +672,14 @@ func (re *Regexp) NumSubexp() int {
 // with the replacement text repl. Inside repl, $ signs are interpreted as
 // in Expand, so for instance $1 represents the text of the first submatch.
 func (re *Regexp) ReplaceAll(src, repl []byte) []byte {
-	return re.re().ReplaceAll(src, repl)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyReplace)()
+	}
+	if re.lit != nil && bytes.IndexByte(repl, '$') < 0 {
+		return replaceAllLiteral(re.lit, nonNil(src), "", repl)
+	}
+	return rx.ReplaceAll(src, repl)
 }
 
 // ReplaceAllFunc returns a copy of src in which all matches of the
@@ -331,28 +687,58 @@ func (re *Regexp) ReplaceAll(src, repl []byte) []byte {
 // to the matched byte slice. The replacement returned by repl is substituted
 // directly, without using Expand.
 func (re *Regexp) ReplaceAllFunc(src []byte, repl func([]byte) []byte) []byte {
-	return re.re().ReplaceAllFunc(src, repl)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyReplace)()
+	}
+	if re.lit != nil {
+		return re.lit.replaceAll(nonNil(src), "", func(dst []byte, start, end int) []byte {
+			return append(dst, repl(src[start:end])...)
+		})
+	}
+	return rx.ReplaceAllFunc(src, repl)
 }
 
 // ReplaceAllLiteral returns a copy of src, replacing matches of the Regexp
 // with the replacement bytes repl. The replacement repl is substituted directly,
 // without using Expand.
 func (re *Regexp) ReplaceAllLiteral(src, repl []byte) []byte {
-	return re.re().ReplaceAllLiteral(src, repl)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyReplace)()
+	}
+	if re.lit != nil {
+		return replaceAllLiteral(re.lit, nonNil(src), "", repl)
+	}
+	return rx.ReplaceAllLiteral(src, repl)
 }
 
 // ReplaceAllLiteralString returns a copy of src, replacing matches of the Regexp
 // with the replacement string repl. The replacement repl is substituted directly,
 // without using Expand.
 func (re *Regexp) ReplaceAllLiteralString(src, repl string) string {
-	return re.re().ReplaceAllLiteralString(src, repl)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyReplace)()
+	}
+	if re.lit != nil {
+		return string(replaceAllLiteral(re.lit, nil, src, repl))
+	}
+	return rx.ReplaceAllLiteralString(src, repl)
 }
 
 // ReplaceAllString returns a copy of src, replacing matches of the Regexp
 // with the replacement string repl. Inside repl, $ signs are interpreted as
 // in Expand, so for instance $1 represents the text of the first submatch.
 func (re *Regexp) ReplaceAllString(src, repl string) string {
-	return re.re().ReplaceAllString(src, repl)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyReplace)()
+	}
+	if re.lit != nil && !strings.Contains(repl, "$") {
+		return string(replaceAllLiteral(re.lit, nil, src, repl))
+	}
+	return rx.ReplaceAllString(src, repl)
 }
 
 // ReplaceAllStringFunc returns a copy of src in which all matches of the
@@ -360,7 +746,16 @@ func (re *Regexp) ReplaceAllString(src, repl string) string {
 // to the matched substring. The replacement returned by repl is substituted
 // directly, without using Expand.
 func (re *Regexp) ReplaceAllStringFunc(src string, repl func(string) string) string {
-	return re.re().ReplaceAllStringFunc(src, repl)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familyReplace)()
+	}
+	if re.lit != nil {
+		return string(re.lit.replaceAll(nil, src, func(dst []byte, start, end int) []byte {
+			return append(dst, repl(src[start:end])...)
+		}))
+	}
+	return rx.ReplaceAllStringFunc(src, repl)
 }
 
 // Split slices s into substrings separated by the expression and returns a slice of
@@ -380,7 +775,14 @@ func (re *Regexp) ReplaceAllStringFunc(src string, repl func(string) string) str
 //   - n == 0: the result is nil (zero substrings);
 //   - n < 0: all substrings.
 func (re *Regexp) Split(s string, n int) []string {
-	return re.re().Split(s, n)
+	rx := re.re()
+	if statsEnabled.Load() {
+		defer re.observe(familySplit)()
+	}
+	if re.lit != nil {
+		return re.lit.split(s, n)
+	}
+	return rx.Split(s, n)
 }
 
 // String returns the source text used to compile the regular expression.
@@ -5,10 +5,12 @@ package reonce
 
 import (
 	"bytes"
-	"io"
+	"errors"
+	"fmt"
 	"math/rand"
 	"reflect"
 	"regexp"
+	"regexp/syntax"
 	"runtime"
 	"strings"
 	"sync"
@@ -58,10 +60,16 @@ func TestMustCompileError(t *testing.T) {
 	mustPanic := func(t *testing.T, fn func()) (msg string) {
 		defer func() {
 			e := recover()
-			if e == nil {
+			switch v := e.(type) {
+			case nil:
 				t.Error("no panic")
+			case string:
+				msg = v
+			case error:
+				msg = v.Error()
+			default:
+				t.Errorf("unexpected panic type: %T", e)
 			}
-			msg = e.(string)
 		}()
 		fn()
 		return msg
@@ -74,8 +82,13 @@ func TestMustCompileError(t *testing.T) {
 			if posix {
 				exp = mustPanic(t, func() { regexp.MustCompilePOSIX(expr) })
 				got = mustPanic(t, func() { NewPOSIX(expr).MustCompile() })
+			} else {
+				exp = mustPanic(t, func() { regexp.MustCompile(expr) })
+				got = mustPanic(t, func() { New(expr).MustCompile() })
 			}
-			if got != exp {
+			// the panic includes the declaration site after the
+			// standard library's message
+			if !strings.HasPrefix(got, exp) {
 				t.Errorf("%d: %q: expected panic: %s got: %s", i, expr, exp, got)
 			}
 		}
@@ -85,6 +98,75 @@ func TestMustCompileError(t *testing.T) {
 	matchPanic(t, "*", true)
 }
 
+func TestCompileErrorType(t *testing.T) {
+	for _, posix := range []bool{false, true} {
+		var re *Regexp
+		var file string
+		var line int
+		if posix {
+			re = NewPOSIX("[a")
+			_, file, line, _ = runtime.Caller(0)
+		} else {
+			re = New("[a")
+			_, file, line, _ = runtime.Caller(0)
+		}
+		line--
+
+		var cerr *CompileError
+		if !errors.As(re.Compile(), &cerr) {
+			t.Fatalf("Compile: expected a *CompileError got: %T", re.Compile())
+		}
+		if cerr.Expr != "[a" || cerr.POSIX != posix || cerr.File != file || cerr.Line != line {
+			t.Errorf("CompileError: got: %+v", cerr)
+		}
+		var serr *syntax.Error
+		if !errors.As(cerr, &serr) || serr.Code != syntax.ErrMissingBracket {
+			t.Errorf("CompileError: expected to wrap a *syntax.Error got: %#v", cerr.Err)
+		}
+
+		func() {
+			defer func() {
+				e, ok := recover().(*CompileError)
+				if !ok || e != cerr {
+					t.Errorf("expected panic with %v got: %v", cerr, e)
+				}
+			}()
+			re.MatchString("a")
+		}()
+	}
+}
+
+func TestCompileErrorNoCaller(t *testing.T) {
+	e := &CompileError{Expr: "*", Err: errors.New("bad")}
+	if got, want := e.Error(), "regexp: Compile(`*`): bad"; got != want {
+		t.Errorf("Error() = %q want: %q", got, want)
+	}
+}
+
+func TestCaller(t *testing.T) {
+	re := New("*")
+	_, wantFile, wantLine, _ := runtime.Caller(0)
+	wantLine--
+
+	file, line, ok := re.Caller()
+	if !ok || file != wantFile || line != wantLine {
+		t.Errorf("Caller() = %q, %d, %t want: %q, %d, %t", file, line, ok,
+			wantFile, wantLine, true)
+	}
+
+	pos := fmt.Sprintf("%s:%d", wantFile, wantLine)
+	if err := re.Compile(); err == nil || !strings.Contains(err.Error(), pos) {
+		t.Errorf("Compile: expected error to contain %q got: %v", pos, err)
+	}
+	defer func() {
+		msg := fmt.Sprint(recover())
+		if !strings.Contains(msg, pos) {
+			t.Errorf("expected panic to contain %q got: %q", pos, msg)
+		}
+	}()
+	re.MustCompile()
+}
+
 func TestString(t *testing.T) {
 	exprs := []string{
 		"aaa",
@@ -116,7 +198,12 @@ func buildMethodArgs(t *testing.T, method reflect.Value) []reflect.Value {
 		switch typ.Kind() {
 		case reflect.Func:
 			// We need to use real funcs here in case they are called
-			if typ.In(0).Kind() == reflect.String {
+			if typ.NumIn() == 2 {
+				fn := func(match []byte, loc []int64) error {
+					return nil
+				}
+				args[i] = reflect.ValueOf(fn)
+			} else if typ.In(0).Kind() == reflect.String {
 				fn := func(s string) string {
 					return s + s
 				}
@@ -128,10 +215,16 @@ func buildMethodArgs(t *testing.T, method reflect.Value) []reflect.Value {
 				args[i] = reflect.ValueOf(fn)
 			}
 		case reflect.Interface:
-			// We need to use a real io.RuneReader here in case it's called
-			args[i] = reflect.ValueOf((io.RuneReader)(&bytes.Buffer{}))
+			// We need to use a real io.RuneReader (or io.Reader) here in
+			// case it's called
+			args[i] = reflect.ValueOf(&bytes.Buffer{}).Convert(typ)
 		case reflect.String:
 			args[i] = reflect.ValueOf("aaa")
+		case reflect.Int:
+			// Use a fixed count or length since a random int may be too
+			// large to allocate (FindAllReader) and TestTryRegexp
+			// compares the results of calls with different arguments.
+			args[i] = reflect.ValueOf(16)
 		case reflect.Slice:
 			switch typ.Elem().Kind() {
 			case reflect.Uint8:
@@ -153,6 +246,23 @@ func buildMethodArgs(t *testing.T, method reflect.Value) []reflect.Value {
 	return args
 }
 
+// notLazy are methods of Regexp that do not compile it.
+var notLazy = map[string]bool{
+	"AppendText":    true,
+	"Caller":        true,
+	"Compiled":      true,
+	"Err":           true,
+	"MarshalText":   true,
+	"POSIX":         true,
+	"Scan":          true,
+	"Set":           true,
+	"Stats":         true,
+	"String":        true,
+	"Try":           true,
+	"UnmarshalText": true,
+	"Value":         true,
+}
+
 func TestLazyCompile(t *testing.T) {
 	const GoodPattern = ".*"
 
@@ -182,7 +292,7 @@ func TestLazyCompile(t *testing.T) {
 	typ := reflect.TypeOf(&Regexp{})
 	for i := 0; i < typ.NumMethod(); i++ {
 		m := typ.Method(i)
-		if m.Name == "String" {
+		if notLazy[m.Name] {
 			continue
 		}
 		t.Run(m.Name, func(t *testing.T) {
@@ -203,8 +313,8 @@ func TestLazyCompilePanic(t *testing.T) {
 		switch v := e.(type) {
 		case nil:
 			t.Errorf("%s: should have panicked", methodName)
-		case string:
-			if !strings.Contains(v, ErrMessage) {
+		case *CompileError:
+			if !strings.Contains(v.Error(), ErrMessage) {
 				t.Errorf("%s: expected error message to contain: %q got: %q",
 					methodName, ErrMessage, v)
 			}
@@ -225,7 +335,7 @@ func TestLazyCompilePanic(t *testing.T) {
 	typ := reflect.TypeOf(&Regexp{})
 	for i := 0; i < typ.NumMethod(); i++ {
 		m := typ.Method(i)
-		if m.Name == "Compile" || m.Name == "String" {
+		if m.Name == "Compile" || m.Name == "Unmarshal" || m.Name == "FindAllReader" || notLazy[m.Name] {
 			continue
 		}
 		t.Run(m.Name, func(t *testing.T) {
@@ -294,3 +404,75 @@ func BenchmarkInitOverhead_Parallel_Baseline(b *testing.B) {
 		}
 	})
 }
+
+// benchLiteralInput is a long input that does not match the literal
+// patterns used by the literal benchmarks.
+var benchLiteralInput = strings.Repeat("the quick brown fox jumps over the lazy dog ", 32)
+
+func BenchmarkLiteral(b *testing.B) {
+	for _, expr := range []string{`lazy cat`, `^quick`, `cat$`} {
+		b.Run(expr, func(b *testing.B) {
+			re := New(expr)
+			for i := 0; i < b.N; i++ {
+				re.MatchString(benchLiteralInput)
+			}
+		})
+	}
+}
+
+func BenchmarkLiteral_Baseline(b *testing.B) {
+	for _, expr := range []string{`lazy cat`, `^quick`, `cat$`} {
+		b.Run(expr, func(b *testing.B) {
+			re := regexp.MustCompile(expr)
+			for i := 0; i < b.N; i++ {
+				re.MatchString(benchLiteralInput)
+			}
+		})
+	}
+}
+
+func BenchmarkLiteralReplaceAll(b *testing.B) {
+	re := New(`fox`)
+	for i := 0; i < b.N; i++ {
+		re.ReplaceAllString(benchLiteralInput, "cat")
+	}
+}
+
+func BenchmarkLiteralReplaceAll_Baseline(b *testing.B) {
+	re := regexp.MustCompile(`fox`)
+	for i := 0; i < b.N; i++ {
+		re.ReplaceAllString(benchLiteralInput, "cat")
+	}
+}
+
+func BenchmarkRequiredLiterals(b *testing.B) {
+	re := New(`error: .* timeout after \d+s`)
+	for i := 0; i < b.N; i++ {
+		re.MatchString(benchLiteralInput)
+	}
+}
+
+func BenchmarkRequiredLiterals_Baseline(b *testing.B) {
+	re := regexp.MustCompile(`error: .* timeout after \d+s`)
+	for i := 0; i < b.N; i++ {
+		re.MatchString(benchLiteralInput)
+	}
+}
+
+// benchAnchoredInput is a large input that does not start with the prefix
+// of the anchored patterns, which regexp rejects without scanning it.
+var benchAnchoredInput = strings.Repeat("x", 1<<20)
+
+func BenchmarkRequiredLiteralsAnchored(b *testing.B) {
+	re := New(`^abc\d+`)
+	for i := 0; i < b.N; i++ {
+		re.MatchString(benchAnchoredInput)
+	}
+}
+
+func BenchmarkRequiredLiteralsAnchored_Baseline(b *testing.B) {
+	re := regexp.MustCompile(`^abc\d+`)
+	for i := 0; i < b.N; i++ {
+		re.MatchString(benchAnchoredInput)
+	}
+}
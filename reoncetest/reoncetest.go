@@ -0,0 +1,102 @@
+// Package reoncetest provides test helpers that compile every Regexp
+// registered with the reonce package (see reonce.Walk) and report those
+// that are invalid. Unlike the reoncetest build tag, the helpers do not
+// require rebuilding the code under test and report every invalid pattern
+// instead of panicking on the first one.
+//
+// Call CheckAll from a test:
+//
+//	func TestRegexps(t *testing.T) {
+//		reoncetest.CheckAll(t)
+//	}
+//
+// or check the Regexps before running the tests of a package with Main:
+//
+//	func TestMain(m *testing.M) {
+//		reoncetest.Main(m)
+//	}
+//
+// Only Regexps that have been created when the helpers are called are
+// checked, which includes every Regexp declared by a package level variable
+// of the packages linked into the test binary.
+package reoncetest
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/charlievieth/reonce"
+)
+
+// check compiles every registered Regexp and calls report with a message
+// for each one that fails to compile. It returns the number of failures.
+func check(report func(msg string)) int {
+	n := 0
+	reonce.Walk(func(re *reonce.Regexp) bool {
+		if err := re.Compile(); err != nil {
+			report(message(re, err))
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// message returns the description of the compile error err of re, which
+// starts with the declaration site of re, if known.
+func message(re *reonce.Regexp, err error) string {
+	var prefix string
+	if file, line, ok := re.Caller(); ok {
+		prefix = file + ":" + strconv.Itoa(line) + ": "
+	}
+	kind := "regexp"
+	if re.POSIX() {
+		kind = "POSIX regexp"
+	}
+	if ce, ok := err.(*reonce.CompileError); ok {
+		err = ce.Err
+	}
+	return fmt.Sprintf("%sinvalid %s %s: %v", prefix, kind, quote(re.String()), err)
+}
+
+func quote(s string) string {
+	if strconv.CanBackquote(s) {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+// CheckAll compiles every registered Regexp and reports each one that fails
+// to compile as an error of t, naming its pattern and declaration site.
+func CheckAll(t testing.TB) {
+	t.Helper()
+	check(func(msg string) { t.Error(msg) })
+}
+
+// Main checks the registered Regexps, like CheckAll, then runs the tests
+// and exits. Failures are printed to standard error and cause the test
+// binary to exit with a non-zero status after the tests have run. It is
+// meant to be called from TestMain.
+func Main(m *testing.M) {
+	os.Exit(run(m, os.Stderr))
+}
+
+// runner is the subset of testing.M used by run.
+type runner interface {
+	Run() int
+}
+
+func run(m runner, w io.Writer) int {
+	failed := check(func(msg string) { fmt.Fprintln(w, msg) })
+	code := m.Run()
+	if failed > 0 {
+		fmt.Fprintf(w, "FAIL: reoncetest: %d invalid regexp(s)\n", failed)
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
@@ -0,0 +1,104 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reoncetest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charlievieth/reonce"
+)
+
+// The Regexps checked by the tests, invalid patterns are not compiled until
+// they are checked since the package is not built with the reoncetest tag.
+var (
+	_ = reonce.New(`a+`)
+	_ = reonce.New(`a(`)
+	_ = reonce.NewPOSIX(`[a`)
+	_ = reonce.NewPOSIX(`b+`)
+)
+
+var wantMessages = []string{
+	"reoncetest_test.go:19: invalid regexp `a(`: error parsing regexp: missing closing ): `a(`",
+	"reoncetest_test.go:20: invalid POSIX regexp `[a`: error parsing regexp: missing closing ]: `[a`",
+}
+
+func testMessages(t *testing.T, got []string) {
+	t.Helper()
+	if len(got) != len(wantMessages) {
+		t.Fatalf("got %d messages; want: %d\n%s", len(got), len(wantMessages), strings.Join(got, "\n"))
+	}
+	for i, msg := range got {
+		if !strings.HasSuffix(msg, wantMessages[i]) {
+			t.Errorf("message %d:\ngot:  %s\nwant: .../%s", i, msg, wantMessages[i])
+		}
+	}
+}
+
+type fakeTB struct {
+	testing.TB
+	errs []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Error(args ...any) {
+	f.errs = append(f.errs, fmt.Sprint(args...))
+}
+
+func TestCheckAll(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	CheckAll(tb)
+	testMessages(t, tb.errs)
+}
+
+type fakeM struct {
+	code int
+	ran  bool
+}
+
+func (m *fakeM) Run() int {
+	m.ran = true
+	return m.code
+}
+
+func TestRun(t *testing.T) {
+	for _, code := range []int{0, 2} {
+		var buf bytes.Buffer
+		m := &fakeM{code: code}
+		got := run(m, &buf)
+		if !m.ran {
+			t.Error("tests were not run")
+		}
+		want := code
+		if want == 0 {
+			want = 1
+		}
+		if got != want {
+			t.Errorf("run: exit code = %d; want: %d", got, want)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if last := lines[len(lines)-1]; last != "FAIL: reoncetest: 2 invalid regexp(s)" {
+			t.Errorf("last line = %q", last)
+		}
+		testMessages(t, lines[:len(lines)-1])
+	}
+}
+
+func TestMessageUnknownCaller(t *testing.T) {
+	re := new(reonce.Regexp)
+	if err := re.UnmarshalText([]byte(`a(`)); err != nil {
+		t.Fatal(err)
+	}
+	err := re.Compile()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	want := "invalid regexp `a(`: error parsing regexp: missing closing ): `a(`"
+	if got := message(re, err); got != want {
+		t.Errorf("message = %q; want: %q", got, want)
+	}
+}
@@ -4,5 +4,6 @@
 package reonce
 
 // mustCompile forces compilation of the regex in New() and NewPOSIX()
-// and is useful for testing Regexp's declared on initialization.
+// and is useful for testing Regexp's declared on initialization. It makes
+// ModeEager the default Mode.
 const mustCompile = true
@@ -3,7 +3,10 @@
 
 package reonce
 
-import "testing"
+import (
+	"os"
+	"testing"
+)
 
 func TestCompileAlways(t *testing.T) {
 	t.Run("New", func(t *testing.T) {
@@ -24,3 +27,12 @@ func TestCompileAlways(t *testing.T) {
 		NewPOSIX("[")
 	})
 }
+
+func TestCompileAlwaysMode(t *testing.T) {
+	if os.Getenv("REONCE_MODE") != "" {
+		t.Skip("REONCE_MODE is set")
+	}
+	if m := GetMode(); m != ModeEager {
+		t.Errorf("GetMode() = %v; want: %v", m, ModeEager)
+	}
+}
@@ -0,0 +1,204 @@
+package reonce
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+)
+
+var errWriterClosed = errors.New("reonce: write to closed replace writer")
+
+// A replacer replaces the matches of a Regexp in the text of a
+// streamScanner.
+type replacer struct {
+	s       *streamScanner
+	rx      *regexp.Regexp
+	repl    []byte
+	expand  bool   // repl is a template for Expand
+	written int64  // offset in the stream of the text that is not replaced yet
+	out     []byte // replaced text
+	err     error  // sticky error
+}
+
+func (re *Regexp) newReplacer(repl []byte, literal bool) replacer {
+	rx := re.re()
+	if statsEnabled.Load() {
+		re.record(familyReplace)
+	}
+	// Same as regexp.Regexp.ReplaceAll, Expand is only required if the
+	// template contains a '$'.
+	expand := !literal && bytes.IndexByte(repl, '$') >= 0
+	return replacer{
+		s:      re.newStreamScanner(rx, DefaultMaxMatchLen, expand),
+		rx:     rx,
+		repl:   repl,
+		expand: expand,
+	}
+}
+
+// replace appends the replaced text of all of the final matches in the
+// buffered text to out, followed by the text that can be discarded by the
+// streamScanner, or all of the remaining text at EOF.
+func (r *replacer) replace() error {
+	s := r.s
+	for {
+		loc, err := s.next()
+		if err != nil {
+			return err
+		}
+		if loc == nil {
+			break
+		}
+		r.out = append(r.out, s.buf[r.written-s.base:loc[0]]...)
+		if r.expand {
+			r.out = r.rx.Expand(r.out, r.repl, s.buf, loc)
+		} else {
+			r.out = append(r.out, r.repl...)
+		}
+		r.written = s.base + int64(loc[1])
+	}
+	end := len(s.buf)
+	if !s.done() {
+		end = s.discardable()
+	}
+	if i := int(r.written - s.base); i < end {
+		r.out = append(r.out, s.buf[i:end]...)
+		r.written = s.base + int64(end)
+	}
+	return nil
+}
+
+type replaceReader struct {
+	replacer
+	r   io.Reader
+	off int // offset of the unread text in out
+}
+
+// NewReplaceReader returns a reader that reads from r and replaces the
+// matches of the Regexp with the replacement text repl. Inside repl, $
+// signs are interpreted as in Expand. The text read is the same as the
+// result of ReplaceAll if it were called with all of the text read from r,
+// but only a bounded amount of the text is held in memory.
+//
+// Matches may span the boundaries of reads from r. The expression must not
+// match more than DefaultMaxMatchLen bytes, see FindAllReader for details.
+// If a longer match is found, Read returns ErrMatchTooLong and the text
+// that precedes the match may not have been read.
+func (re *Regexp) NewReplaceReader(r io.Reader, repl []byte) io.Reader {
+	return &replaceReader{replacer: re.newReplacer(repl, false), r: r}
+}
+
+// NewReplaceLiteralReader is like NewReplaceReader but the replacement
+// repl is substituted directly, without using Expand. The text read is the
+// same as the result of ReplaceAllLiteral.
+func (re *Regexp) NewReplaceLiteralReader(r io.Reader, repl []byte) io.Reader {
+	return &replaceReader{replacer: re.newReplacer(repl, true), r: r}
+}
+
+func (r *replaceReader) Read(p []byte) (int, error) {
+	for {
+		if r.off < len(r.out) {
+			n := copy(p, r.out[r.off:])
+			r.off += n
+			return n, nil
+		}
+		r.out = r.out[:0]
+		r.off = 0
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.s.done() {
+			r.err = io.EOF
+			continue
+		}
+		if err := r.replace(); err != nil {
+			r.err = err
+			continue
+		}
+		if !r.s.eof {
+			if err := r.s.readFrom(r.r); err != nil {
+				r.err = err
+			}
+		}
+	}
+}
+
+type replaceWriter struct {
+	replacer
+	w io.Writer
+}
+
+// NewReplaceWriter returns a writer that replaces the matches of the Regexp
+// in the text written to it with the replacement text repl and writes the
+// result to w. Inside repl, $ signs are interpreted as in Expand. The text
+// written to w is the same as the result of ReplaceAll if it were called
+// with all of the text written, but only a bounded amount of the text is
+// held in memory.
+//
+// Text is buffered until it is known to not be part of a match, so Close
+// must be called to replace and write the remaining text. Close does not
+// close w. The expression must not match more than DefaultMaxMatchLen
+// bytes, see FindAllReader for details. If a longer match is found, Write
+// or Close returns ErrMatchTooLong.
+func (re *Regexp) NewReplaceWriter(w io.Writer, repl []byte) io.WriteCloser {
+	return &replaceWriter{replacer: re.newReplacer(repl, false), w: w}
+}
+
+// NewReplaceLiteralWriter is like NewReplaceWriter but the replacement
+// repl is substituted directly, without using Expand. The text written to
+// w is the same as the result of ReplaceAllLiteral.
+func (re *Regexp) NewReplaceLiteralWriter(w io.Writer, repl []byte) io.WriteCloser {
+	return &replaceWriter{replacer: re.newReplacer(repl, true), w: w}
+}
+
+func (w *replaceWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n := 0
+	for n < len(p) {
+		n += w.s.write(p[n:])
+		if w.s.full() {
+			if err := w.flush(); err != nil {
+				return n, err
+			}
+			w.s.compact()
+		}
+	}
+	return n, nil
+}
+
+// flush replaces the buffered text and writes the result to w.
+func (w *replaceWriter) flush() error {
+	if err := w.replace(); err != nil {
+		w.err = err
+		return err
+	}
+	if len(w.out) > 0 {
+		_, err := w.w.Write(w.out)
+		w.out = w.out[:0]
+		if err != nil {
+			w.err = err
+			return err
+		}
+	}
+	return nil
+}
+
+// Close replaces the remaining buffered text and writes it to the
+// underlying writer. Subsequent calls to Write return an error.
+func (w *replaceWriter) Close() error {
+	if w.err != nil {
+		if w.err == errWriterClosed {
+			return nil
+		}
+		return w.err
+	}
+	w.s.eof = true
+	if err := w.flush(); err != nil {
+		return err
+	}
+	w.err = errWriterClosed
+	return nil
+}
@@ -0,0 +1,144 @@
+//go:build go1.23 && !reoncetest
+
+package reonce
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var replaceTests = []struct {
+	repl    string
+	literal bool
+}{
+	{"", false},
+	{"x", false},
+	{"<$0>", false},
+	{"[$1|${2}]", false},
+	{"${first}-$last", false},
+	{"$$", false},
+	{"<$0>", true},
+}
+
+// readReplaced reads all of the text of the replacing reader of re.
+func readReplaced(re *Regexp, r io.Reader, repl string, literal bool) (string, error) {
+	var rr io.Reader
+	if literal {
+		rr = re.NewReplaceLiteralReader(r, []byte(repl))
+	} else {
+		rr = re.NewReplaceReader(r, []byte(repl))
+	}
+	b, err := io.ReadAll(rr)
+	return string(b), err
+}
+
+// writeReplaced writes b to the replacing writer of re in chunks of n bytes.
+func writeReplaced(re *Regexp, b []byte, n int, repl string, literal bool) (string, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	if literal {
+		w = re.NewReplaceLiteralWriter(&buf, []byte(repl))
+	} else {
+		w = re.NewReplaceWriter(&buf, []byte(repl))
+	}
+	for len(b) > 0 {
+		p := b[:min(n, len(b))]
+		if _, err := w.Write(p); err != nil {
+			return buf.String(), err
+		}
+		b = b[len(p):]
+	}
+	err := w.Close()
+	return buf.String(), err
+}
+
+func TestReplaceReaderWriter(t *testing.T) {
+	defer func(n int) { minReadSize = n }(minReadSize)
+	minReadSize = 16
+
+	var long []byte
+	for len(long) < 1024 {
+		long = append(long, strings.Join(matchTests.inputs, "\n")...)
+		long = append(long, '\n')
+	}
+	inputs := append(matchTests.inputs, string(long))
+
+	res, rxs := testRegexps()
+	for i, re := range res {
+		rx := rxs[i]
+		for _, s := range inputs {
+			b := []byte(s)
+			for _, tt := range replaceTests {
+				var want string
+				if tt.literal {
+					want = string(rx.ReplaceAllLiteral(b, []byte(tt.repl)))
+				} else {
+					want = string(rx.ReplaceAll(b, []byte(tt.repl)))
+				}
+				check := func(name string, got string, err error) {
+					t.Helper()
+					if err != nil {
+						t.Fatalf("%s: %s(%q, %q): %v", re, name, s, tt.repl, err)
+					}
+					if got != want {
+						t.Errorf("%s (posix=%t longest=%t): %s(%q, %q):\ngot:  %q\nwant: %q",
+							re, re.opts.POSIX, re.opts.Longest, name, s, tt.repl, got, want)
+					}
+				}
+				got, err := readReplaced(re, iotest.HalfReader(bytes.NewReader(b)), tt.repl, tt.literal)
+				check("ReplaceReader", got, err)
+				got, err = writeReplaced(re, b, 7, tt.repl, tt.literal)
+				check("ReplaceWriter", got, err)
+			}
+		}
+	}
+}
+
+func TestReplaceReaderMatchTooLong(t *testing.T) {
+	input := "abc" + strings.Repeat("x", DefaultMaxMatchLen+1)
+	re := New(`x+`)
+	if _, err := readReplaced(re, strings.NewReader(input), "y", false); err != ErrMatchTooLong {
+		t.Errorf("ReplaceReader: got: %v want: %v", err, ErrMatchTooLong)
+	}
+	if _, err := writeReplaced(re, []byte(input), 4096, "y", false); err != ErrMatchTooLong {
+		t.Errorf("ReplaceWriter: got: %v want: %v", err, ErrMatchTooLong)
+	}
+}
+
+type errWriter struct{ err error }
+
+func (w errWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestReplaceWriterErrors(t *testing.T) {
+	errWrite := errors.New("write error")
+	w := New(`a`).NewReplaceWriter(errWriter{errWrite}, []byte("b"))
+	if _, err := w.Write([]byte("aaa")); err != nil {
+		t.Fatal(err) // buffered
+	}
+	if err := w.Close(); err != errWrite {
+		t.Errorf("Close: got: %v want: %v", err, errWrite)
+	}
+
+	w = New(`a`).NewReplaceWriter(io.Discard, []byte("b"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if _, err := w.Write([]byte("a")); err == nil {
+		t.Error("Write after Close should return an error")
+	}
+}
+
+func TestReplaceReaderNeverMatch(t *testing.T) {
+	re := NewWithOptions(`(`, Options{OnError: NeverMatch(nil)})
+	got, err := readReplaced(re, strings.NewReader("(a("), "x", false)
+	if err != nil || got != "(a(" {
+		t.Errorf("ReplaceReader = %q, %v want: %q, %v", got, err, "(a(", nil)
+	}
+}
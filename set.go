@@ -0,0 +1,304 @@
+package reonce
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// A Set is a lazily compiled set of patterns that reports which of the
+// patterns match an input in a single scan of the input, like RE2::Set.
+// The patterns are compiled, and combined into a single matcher, on first
+// use. A Set is safe for concurrent use by multiple goroutines.
+//
+// Each pattern of a Set is recorded in the registry (see Walk) as a Regexp
+// declared at the call to NewSet, so invalid patterns are reported by
+// ValidateAll.
+type Set struct {
+	res  []*Regexp
+	once sync.Once
+	bad  int // index of the first pattern that failed to compile, or -1
+	prog *setProg
+	any  *regexp.Regexp // alternation of all of the patterns
+}
+
+// NewSet returns a new lazily compiled Set of the patterns exprs.
+func NewSet(exprs []string) *Set {
+	return newSet(exprs, Options{}, callerPC())
+}
+
+// NewSetWithOptions returns a new lazily compiled Set of the patterns exprs
+// that are each compiled using opts. The Longest option has no effect on a
+// Set since it only reports if the patterns match.
+func NewSetWithOptions(exprs []string, opts Options) *Set {
+	return newSet(exprs, opts, callerPC())
+}
+
+func newSet(exprs []string, opts Options, pc uintptr) *Set {
+	s := &Set{res: make([]*Regexp, len(exprs))}
+	for i, expr := range exprs {
+		s.res[i] = newRegexp(expr, opts, pc)
+	}
+	return s
+}
+
+func (s *Set) init() {
+	s.bad = -1
+	trees := make([]*syntax.Regexp, len(s.res))
+	for i, re := range s.res {
+		if err := re.Compile(); err != nil {
+			s.bad = i
+			return
+		}
+		trees[i], _ = parseCompiled(re.rx, &re.opts)
+	}
+	if len(trees) == 0 {
+		return
+	}
+	alts := make([]string, len(trees))
+	for i, tree := range trees {
+		alts[i] = "(?:" + tree.String() + ")"
+	}
+	s.any = regexp.MustCompile(strings.Join(alts, "|"))
+	s.prog = newSetProg(trees)
+}
+
+// compiled compiles the Set and returns its program. If a pattern failed to
+// compile its ErrorHandler is called and, if the handler returns, nil is
+// returned.
+func (s *Set) compiled() *setProg {
+	s.once.Do(s.init)
+	if s.bad >= 0 {
+		s.res[s.bad].handleError()
+		return nil
+	}
+	return s.prog
+}
+
+// Compile compiles every pattern of the Set and returns the *CompileError
+// of the first pattern that failed to compile, if any. Like
+// Regexp.Compile, this is a no-op if the Set was already compiled.
+func (s *Set) Compile() error {
+	s.once.Do(s.init)
+	if s.bad >= 0 {
+		return s.res[s.bad].err
+	}
+	return nil
+}
+
+// Len returns the number of patterns in the Set.
+func (s *Set) Len() int { return len(s.res) }
+
+// Regexp returns the Regexp of the i'th pattern of the Set.
+func (s *Set) Regexp(i int) *Regexp { return s.res[i] }
+
+// Matches returns the indexes, in increasing order, of the patterns that
+// match b. A return value of nil indicates no match.
+//
+// If a pattern of the Set failed to compile, its ErrorHandler is called
+// and, if the handler returns, the Set never matches.
+func (s *Set) Matches(b []byte) []int {
+	if p := s.compiled(); p != nil {
+		return p.match(b, "")
+	}
+	return nil
+}
+
+// MatchesString is like Matches but matches the string s.
+func (s *Set) MatchesString(str string) []int {
+	if p := s.compiled(); p != nil {
+		return p.match(nil, str)
+	}
+	return nil
+}
+
+// MatchAny reports whether any of the patterns of the Set match b.
+func (s *Set) MatchAny(b []byte) bool {
+	return s.compiled() != nil && s.any.Match(b)
+}
+
+// MatchAnyString reports whether any of the patterns of the Set match the
+// string s.
+func (s *Set) MatchAnyString(str string) bool {
+	return s.compiled() != nil && s.any.MatchString(str)
+}
+
+// A setProg is the combined program of the patterns of a Set. It is run by
+// a simulation of the NFA of all of the patterns at once that only tracks
+// which patterns have reached a match, not the positions of matches.
+type setProg struct {
+	// inst are the instructions of the programs of all of the patterns
+	// relocated to a single slice. The Arg of an InstMatch instruction is
+	// the index of the pattern it belongs to.
+	inst  []syntax.Inst
+	start []uint32 // start instruction of each pattern
+	pool  sync.Pool
+}
+
+func newSetProg(trees []*syntax.Regexp) *setProg {
+	p := &setProg{start: make([]uint32, len(trees))}
+	for i, tree := range trees {
+		prog, err := syntax.Compile(tree.Simplify())
+		if err != nil {
+			panic("reonce: compiling Set pattern: " + err.Error())
+		}
+		off := uint32(len(p.inst))
+		p.start[i] = off + uint32(prog.Start)
+		for _, inst := range prog.Inst {
+			switch inst.Op {
+			case syntax.InstAlt, syntax.InstAltMatch:
+				inst.Out += off
+				inst.Arg += off
+			case syntax.InstMatch:
+				inst.Arg = uint32(i)
+			case syntax.InstFail:
+			default:
+				inst.Out += off
+			}
+			p.inst = append(p.inst, inst)
+		}
+	}
+	return p
+}
+
+// A sparseSet is a set of instructions that can be cleared in constant
+// time.
+type sparseSet struct {
+	sparse []uint32
+	dense  []uint32
+}
+
+func (s *sparseSet) contains(pc uint32) bool {
+	i := s.sparse[pc]
+	return int(i) < len(s.dense) && s.dense[i] == pc
+}
+
+func (s *sparseSet) insert(pc uint32) {
+	s.sparse[pc] = uint32(len(s.dense))
+	s.dense = append(s.dense, pc)
+}
+
+// A setMachine holds the state used to run a setProg.
+type setMachine struct {
+	visited sparseSet // instructions visited at the current position
+	next    []uint32  // instructions to follow at the next position
+	stack   []uint32
+	matched []bool
+	nmatch  int
+}
+
+func (p *setProg) machine() *setMachine {
+	if m, ok := p.pool.Get().(*setMachine); ok {
+		clear(m.matched)
+		m.nmatch = 0
+		m.next = m.next[:0]
+		return m
+	}
+	return &setMachine{
+		visited: sparseSet{
+			sparse: make([]uint32, len(p.inst)),
+			dense:  make([]uint32, 0, len(p.inst)),
+		},
+		matched: make([]bool, len(p.start)),
+	}
+}
+
+// add adds pc and the instructions reachable from it without consuming a
+// rune at a position with the empty-width context ctx to the visited set
+// and records the patterns that match.
+func (p *setProg) add(m *setMachine, pc uint32, ctx syntax.EmptyOp) {
+	m.stack = append(m.stack[:0], pc)
+	for len(m.stack) > 0 {
+		pc := m.stack[len(m.stack)-1]
+		m.stack = m.stack[:len(m.stack)-1]
+		if m.visited.contains(pc) {
+			continue
+		}
+		m.visited.insert(pc)
+		inst := &p.inst[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			m.stack = append(m.stack, inst.Arg, inst.Out)
+		case syntax.InstCapture, syntax.InstNop:
+			m.stack = append(m.stack, inst.Out)
+		case syntax.InstEmptyWidth:
+			if syntax.EmptyOp(inst.Arg)&^ctx == 0 {
+				m.stack = append(m.stack, inst.Out)
+			}
+		case syntax.InstMatch:
+			if !m.matched[inst.Arg] {
+				m.matched[inst.Arg] = true
+				m.nmatch++
+			}
+		}
+	}
+}
+
+// match returns the indexes of the patterns that match b (or s, if b is
+// nil).
+func (p *setProg) match(b []byte, s string) []int {
+	m := p.machine()
+	defer p.pool.Put(m)
+
+	end := len(s)
+	if b != nil {
+		end = len(b)
+	}
+	prev := rune(-1)
+	for pos := 0; ; {
+		r, width := rune(-1), 0
+		if pos < end {
+			if b != nil {
+				r, width = utf8.DecodeRune(b[pos:])
+			} else {
+				r, width = utf8.DecodeRuneInString(s[pos:])
+			}
+		}
+		ctx := syntax.EmptyOpContext(prev, r)
+		m.visited.dense = m.visited.dense[:0]
+		for _, pc := range m.next {
+			p.add(m, pc, ctx)
+		}
+		// The patterns are unanchored so start a new thread of the
+		// patterns that have not matched yet at every position.
+		for i, pc := range p.start {
+			if !m.matched[i] {
+				p.add(m, pc, ctx)
+			}
+		}
+		if m.nmatch == len(p.start) || r < 0 {
+			break
+		}
+		m.next = m.next[:0]
+		for _, pc := range m.visited.dense {
+			inst := &p.inst[pc]
+			var ok bool
+			switch inst.Op {
+			case syntax.InstRune, syntax.InstRune1:
+				ok = inst.MatchRune(r)
+			case syntax.InstRuneAny:
+				ok = true
+			case syntax.InstRuneAnyNotNL:
+				ok = r != '\n'
+			}
+			if ok {
+				m.next = append(m.next, inst.Out)
+			}
+		}
+		prev = r
+		pos += width
+	}
+
+	if m.nmatch == 0 {
+		return nil
+	}
+	matches := make([]int, 0, m.nmatch)
+	for i, ok := range m.matched {
+		if ok {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
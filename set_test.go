@@ -0,0 +1,121 @@
+//go:build go1.23 && !reoncetest
+
+package reonce
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// setMatches returns the indexes of the Regexps in rxs that match s.
+func setMatches(rxs []*regexp.Regexp, s string) []int {
+	var matches []int
+	for i, rx := range rxs {
+		if rx.MatchString(s) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+func TestSet(t *testing.T) {
+	test := func(t *testing.T, set *Set, rxs []*regexp.Regexp) {
+		t.Helper()
+		for _, s := range matchTests.inputs {
+			want := setMatches(rxs, s)
+			if got := set.MatchesString(s); !reflect.DeepEqual(got, want) {
+				t.Errorf("MatchesString(%q) = %v want: %v", s, got, want)
+			}
+			if got := set.Matches([]byte(s)); !reflect.DeepEqual(got, want) {
+				t.Errorf("Matches(%q) = %v want: %v", s, got, want)
+			}
+			if got := set.MatchAnyString(s); got != (want != nil) {
+				t.Errorf("MatchAnyString(%q) = %t want: %t", s, got, want != nil)
+			}
+			if got := set.MatchAny([]byte(s)); got != (want != nil) {
+				t.Errorf("MatchAny(%q) = %t want: %t", s, got, want != nil)
+			}
+		}
+	}
+	t.Run("Perl", func(t *testing.T) {
+		var rxs []*regexp.Regexp
+		for _, expr := range matchTests.exprs {
+			rxs = append(rxs, regexp.MustCompile(expr))
+		}
+		test(t, NewSet(matchTests.exprs), rxs)
+	})
+	t.Run("POSIX", func(t *testing.T) {
+		var rxs []*regexp.Regexp
+		for _, expr := range matchTests.posix {
+			rxs = append(rxs, regexp.MustCompilePOSIX(expr))
+		}
+		test(t, NewSetWithOptions(matchTests.posix, Options{POSIX: true}), rxs)
+	})
+	t.Run("Options", func(t *testing.T) {
+		exprs := []string{`A.B`, `^b`, `a$`}
+		var rxs []*regexp.Regexp
+		for _, expr := range exprs {
+			rxs = append(rxs, regexp.MustCompile(`(?ism)`+expr))
+		}
+		set := NewSetWithOptions(exprs, Options{FoldCase: true, DotNL: true, MultiLine: true})
+		test(t, set, rxs)
+		if got := set.MatchesString("a\nb"); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+			t.Errorf("MatchesString = %v want: %v", got, []int{0, 1, 2})
+		}
+	})
+}
+
+func TestSetRandom(t *testing.T) {
+	atoms := []string{"a", "b", " ", `\b`, `\B`, "^", "$", "(?m:^)", "(?m:$)", "a*", "b+?",
+		"(a|b)", "(|a)", ".", `\w`, "☺", `\A`, `\z`, "(a*)", "[^a]", "(?i:A)"}
+	const chars = "ab ☺\n�"
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 500; i++ {
+		var exprs []string
+		var rxs []*regexp.Regexp
+		for j := r.Intn(8); j >= 0; j-- {
+			expr, _ := randomMatchTest(r, atoms, chars)
+			exprs = append(exprs, expr)
+			rxs = append(rxs, regexp.MustCompile(expr))
+		}
+		set := NewSet(exprs)
+		for j := 0; j < 10; j++ {
+			_, s := randomMatchTest(r, atoms, chars)
+			if got, want := set.MatchesString(s), setMatches(rxs, s); !reflect.DeepEqual(got, want) {
+				t.Fatalf("%q: MatchesString(%q) = %v want: %v", exprs, s, got, want)
+			}
+		}
+	}
+}
+
+func TestSetEmpty(t *testing.T) {
+	set := NewSet(nil)
+	if set.Compile() != nil || set.MatchesString("") != nil || set.MatchAnyString("") {
+		t.Error("an empty Set should never match")
+	}
+}
+
+func TestSetCompileError(t *testing.T) {
+	var reports []*CompileError
+	set := NewSetWithOptions([]string{`a`, `(`, `[`}, Options{
+		OnError: func(err *CompileError) { reports = append(reports, err) },
+	})
+	var cerr *CompileError
+	if err := set.Compile(); !errors.As(err, &cerr) || cerr.Expr != `(` {
+		t.Fatalf("Compile: expected a *CompileError for %q got: %v", `(`, err)
+	}
+	if set.MatchesString("a") != nil || set.MatchAnyString("a") {
+		t.Error("Set should never match")
+	}
+	if len(reports) != 2 || reports[0] != cerr {
+		t.Errorf("ErrorHandler should be called with the error: %v", reports)
+	}
+	if _, _, ok := set.Regexp(1).Caller(); !ok {
+		t.Error("Set patterns should record their declaration site")
+	}
+}
@@ -0,0 +1,92 @@
+package reonce
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// Scan implements sql.Scanner. The pattern, which may be a string or a
+// []byte, is stored and lazily compiled on first use. The options of the
+// Regexp are preserved. Scanning a NULL value is an error, use NullRegexp
+// for nullable columns and StrictRegexp to compile the pattern when it is
+// scanned.
+//
+// Scan resets the Regexp and may not be called concurrently with any other
+// methods. Like UnmarshalText, it removes a registered Regexp from the
+// registry and the background compile queue.
+func (re *Regexp) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		re.reset(v)
+	case []byte:
+		re.reset(string(v))
+	case nil:
+		return errors.New("reonce: cannot scan NULL into *Regexp, use NullRegexp")
+	default:
+		return fmt.Errorf("reonce: cannot scan type %T into *Regexp", src)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer and returns the pattern of the Regexp as a
+// string. It does not compile the Regexp.
+func (re *Regexp) Value() (driver.Value, error) {
+	return re.expr, nil
+}
+
+// Scan implements sql.Scanner. Unlike Regexp.Scan the pattern is compiled
+// immediately and the *CompileError, if any, is returned.
+func (re *StrictRegexp) Scan(src any) error {
+	if err := re.Regexp.Scan(src); err != nil {
+		return err
+	}
+	return re.Compile()
+}
+
+// NullRegexp represents a Regexp that may be NULL. NullRegexp implements
+// the sql.Scanner interface so it can be used as a scan destination:
+//
+//	var re reonce.NullRegexp
+//	err := db.QueryRow("SELECT pattern FROM filters WHERE id=?", id).Scan(&re)
+//	...
+//	if re.Valid {
+//		// use re.Regexp
+//	} else {
+//		// NULL value
+//	}
+//
+// Scanning a non-NULL value stores a new Regexp in the Regexp field, which
+// uses the options of the previous Regexp, if any, and scanning NULL sets
+// it to nil. A NullRegexp may be copied and passed by value as a query
+// argument.
+type NullRegexp struct {
+	Regexp *Regexp
+	Valid  bool // Valid is true if Regexp is not NULL
+}
+
+// Scan implements sql.Scanner.
+func (n *NullRegexp) Scan(src any) error {
+	if src == nil {
+		n.Regexp, n.Valid = nil, false
+		return nil
+	}
+	re := new(Regexp)
+	if n.Regexp != nil {
+		re.opts = n.Regexp.opts
+	}
+	if err := re.Scan(src); err != nil {
+		n.Valid = false
+		return err
+	}
+	n.Regexp, n.Valid = re, true
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (n NullRegexp) Value() (driver.Value, error) {
+	if !n.Valid || n.Regexp == nil {
+		return nil, nil
+	}
+	return n.Regexp.Value()
+}
@@ -0,0 +1,193 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+var (
+	_ sql.Scanner   = (*NullRegexp)(nil)
+	_ driver.Valuer = NullRegexp{}
+)
+
+// fakeDriver is an in-memory database/sql driver with a single table that
+// has a single column. "INSERT" appends its argument to the table and
+// "SELECT" returns every row.
+type fakeDriver struct {
+	mu   sync.Mutex
+	rows []driver.Value
+}
+
+type fakeConn struct{ d *fakeDriver }
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+type fakeRows struct {
+	rows []driver.Value
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.d, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.query != "INSERT" || len(args) != 1 {
+		return nil, errors.New("invalid query")
+	}
+	s.d.mu.Lock()
+	s.d.rows = append(s.d.rows, args[0])
+	s.d.mu.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.query != "SELECT" {
+		return nil, errors.New("invalid query")
+	}
+	s.d.mu.Lock()
+	rows := append([]driver.Value(nil), s.d.rows...)
+	s.d.mu.Unlock()
+	return &fakeRows{rows: rows}, nil
+}
+
+func (r *fakeRows) Columns() []string { return []string{"pattern"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	dest[0] = r.rows[0]
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func openFakeDB(t *testing.T) (*sql.DB, *fakeDriver) {
+	d := new(fakeDriver)
+	db := sql.OpenDB(fakeConnector{d})
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+type fakeConnector struct{ d *fakeDriver }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c fakeConnector) Driver() driver.Driver                        { return c.d }
+
+func TestSQL(t *testing.T) {
+	db, d := openFakeDB(t)
+
+	for _, arg := range []any{
+		New(`a+`),
+		NullRegexp{Regexp: New(`b+`), Valid: true}, // by value
+		&NullRegexp{},
+		NewPOSIX(`(c`),
+	} {
+		if _, err := db.Exec("INSERT", arg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []driver.Value{"a+", "b+", nil, "(c"}
+	if !reflect.DeepEqual(d.rows, want) {
+		t.Fatalf("inserted rows: got: %q want: %q", d.rows, want)
+	}
+
+	scan := func(dest func() any) []error {
+		rows, err := db.Query("SELECT")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var errs []error
+		for rows.Next() {
+			errs = append(errs, rows.Scan(dest()))
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return errs
+	}
+
+	// Regexp
+	var res []*Regexp
+	errs := scan(func() any {
+		res = append(res, NewPOSIX(""))
+		return res[len(res)-1]
+	})
+	if errs[0] != nil || errs[1] != nil || errs[2] == nil || errs[3] != nil {
+		t.Errorf("Regexp: unexpected errors: %v", errs)
+	}
+	if res[0].Compiled() || !res[0].MatchString("aa") || !res[0].POSIX() {
+		t.Errorf("Regexp: got: %q", res[0].String())
+	}
+
+	// NullRegexp
+	var nulls []*NullRegexp
+	errs = scan(func() any {
+		nulls = append(nulls, &NullRegexp{Regexp: NewPOSIX(""), Valid: true})
+		return nulls[len(nulls)-1]
+	})
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("NullRegexp %d: %v", i, err)
+		}
+	}
+	if !nulls[0].Valid || nulls[2].Valid || nulls[2].Regexp != nil || nulls[1].Regexp.String() != "b+" {
+		t.Errorf("NullRegexp: got: %+v", nulls)
+	}
+	if !nulls[1].Regexp.POSIX() || nulls[1].Regexp.Compiled() {
+		t.Error("NullRegexp: scanned Regexp should be lazy and keep the options of the previous Regexp")
+	}
+
+	// StrictRegexp
+	errs = scan(func() any { return new(StrictRegexp) })
+	var cerr *CompileError
+	if errs[0] != nil || errs[1] != nil || errs[2] == nil || !errors.As(errs[3], &cerr) {
+		t.Errorf("StrictRegexp: unexpected errors: %v", errs)
+	}
+}
+
+func TestScanInvalidType(t *testing.T) {
+	var re Regexp
+	if err := re.Scan(1); err == nil {
+		t.Error("expected an error")
+	}
+	var n NullRegexp
+	if err := n.Scan(1); err == nil || n.Valid {
+		t.Errorf("NullRegexp: expected an error got: %v, Valid: %t", err, n.Valid)
+	}
+	if err := re.Scan([]byte("a")); err != nil || re.String() != "a" {
+		t.Errorf("Scan([]byte): got: %q, %v", re.String(), err)
+	}
+}
+
+// Scanning into a registered Regexp must not race with the background
+// compilation of the Regexp, run with -race.
+func TestScanBackground(t *testing.T) {
+	withRegistry(t)
+	setMode(t, ModeBackground)
+	for i := 0; i < 100; i++ {
+		re := New(`default\d+`)
+		if err := re.Scan("x+"); err != nil {
+			t.Fatal(err)
+		}
+		if isRegistered(re) || !re.MatchString("xx") {
+			t.Fatalf("scanned Regexp: %q registered: %t", re, isRegistered(re))
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package reonce
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// statsSampleRate is the rate at which calls are sampled to measure their
+// latency, one in every statsSampleRate calls of each method family.
+const statsSampleRate = 64
+
+var statsEnabled atomic.Bool
+
+// EnableStats enables or disables the collection of usage statistics for
+// all Regexps and returns the previous setting. Statistics are disabled by
+// default and, when disabled, collecting them costs a single atomic load per
+// method call. The compile time of a Regexp is always recorded. See Stats.
+func EnableStats(enabled bool) (prev bool) {
+	return statsEnabled.Swap(enabled)
+}
+
+// A methodFamily is a group of related methods of Regexp for which calls
+// are counted together.
+type methodFamily uint8
+
+const (
+	familyMatch   methodFamily = iota // Match, MatchString and MatchReader
+	familyFind                        // Find methods, iterators, FindAllReader and Unmarshal
+	familyReplace                     // ReplaceAll, Expand and the replace readers and writers
+	familySplit                       // Split
+	numFamilies
+)
+
+// regexpStats are the usage statistics of a Regexp, it is allocated when
+// the Regexp is first used while statistics are enabled.
+type regexpStats struct {
+	firstUse    atomic.Int64 // UnixNano, zero if not set
+	calls       [numFamilies]atomic.Uint64
+	samples     atomic.Uint64
+	sampleNanos atomic.Uint64
+}
+
+// Stats are the usage statistics of a Regexp. Calls and latencies are only
+// recorded while statistics are enabled with EnableStats.
+type Stats struct {
+	Expr     string // the pattern
+	POSIX    bool   // the pattern uses POSIX syntax
+	File     string // file where the Regexp was declared, empty if unknown
+	Line     int    // line where the Regexp was declared
+	Compiled bool   // the Regexp has been compiled
+	Err      error  // compile error, if any
+
+	CompiledAt  time.Time     // time the Regexp was compiled, zero if not compiled
+	CompileTime time.Duration // time taken to compile the Regexp
+	FirstUse    time.Time     // time of the first recorded call, zero if none
+
+	// Number of calls to each family of methods. Iterators, such as All,
+	// are counted each time they are iterated and streams, such as
+	// FindAllReader and NewReplaceReader, once per stream.
+	MatchCalls   uint64 // Match, MatchString and MatchReader
+	FindCalls    uint64 // Find, FindAll, FindMatch, All, FindAllReader, Unmarshal and their variants
+	ReplaceCalls uint64 // ReplaceAll, Expand, NewReplaceReader, NewReplaceWriter and their variants
+	SplitCalls   uint64 // Split
+
+	// Latency of sampled calls, one in every 64 calls of each family of
+	// methods is sampled. The latency of iterators and streams, which
+	// depends on the caller, is not sampled.
+	Samples     uint64        // number of sampled calls
+	SampledTime time.Duration // total duration of the sampled calls
+}
+
+// Calls returns the total number of recorded method calls.
+func (s *Stats) Calls() uint64 {
+	return s.MatchCalls + s.FindCalls + s.ReplaceCalls + s.SplitCalls
+}
+
+// MeanLatency returns the mean duration of the sampled method calls or zero
+// if no calls were sampled.
+func (s *Stats) MeanLatency() time.Duration {
+	if s.Samples == 0 {
+		return 0
+	}
+	return s.SampledTime / time.Duration(s.Samples)
+}
+
+// Stats returns the usage statistics of the Regexp. It does not compile
+// the Regexp.
+func (re *Regexp) Stats() Stats {
+	file, line, _ := re.Caller()
+	s := Stats{
+		Expr:     re.expr,
+		POSIX:    re.opts.POSIX,
+		File:     file,
+		Line:     line,
+		Compiled: re.Compiled(),
+		Err:      re.Err(),
+	}
+	if s.Compiled {
+		s.CompiledAt = time.Unix(0, re.compiledAt)
+		s.CompileTime = re.compileTime
+	}
+	if st := re.stats.Load(); st != nil {
+		if t := st.firstUse.Load(); t != 0 {
+			s.FirstUse = time.Unix(0, t)
+		}
+		s.MatchCalls = st.calls[familyMatch].Load()
+		s.FindCalls = st.calls[familyFind].Load()
+		s.ReplaceCalls = st.calls[familyReplace].Load()
+		s.SplitCalls = st.calls[familySplit].Load()
+		s.Samples = st.samples.Load()
+		s.SampledTime = time.Duration(st.sampleNanos.Load())
+	}
+	return s
+}
+
+// AllStats returns the usage statistics of every registered Regexp (see
+// Walk) in the order they were created.
+func AllStats() []Stats {
+	list := registered()
+	stats := make([]Stats, len(list))
+	for i, re := range list {
+		stats[i] = re.Stats()
+	}
+	return stats
+}
+
+func noop() {}
+
+// record records a call to a method of family f and reports if its latency
+// should be sampled. Like observe, it must only be called when statistics
+// are enabled. It is used directly by iterators and streams, whose latency
+// depends on the caller and is not sampled.
+func (re *Regexp) record(f methodFamily) (st *regexpStats, sample bool) {
+	st = re.stats.Load()
+	if st == nil {
+		st = new(regexpStats)
+		if !re.stats.CompareAndSwap(nil, st) {
+			st = re.stats.Load()
+		}
+	}
+	if st.firstUse.Load() == 0 {
+		st.firstUse.CompareAndSwap(0, time.Now().UnixNano())
+	}
+	return st, st.calls[f].Add(1)%statsSampleRate == 1
+}
+
+// observe records a call to a method of family f and returns a function
+// that must be called when the method returns. It must only be called
+// when statistics are enabled:
+//
+//	if statsEnabled.Load() {
+//		defer re.observe(familyMatch)()
+//	}
+func (re *Regexp) observe(f methodFamily) func() {
+	st, sample := re.record(f)
+	if !sample {
+		return noop
+	}
+	start := time.Now()
+	return func() {
+		st.samples.Add(1)
+		st.sampleNanos.Add(uint64(time.Since(start)))
+	}
+}
@@ -0,0 +1,88 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	re := New(`a+`)
+	if s := re.Stats(); s.Compiled || !s.CompiledAt.IsZero() || s.Calls() != 0 {
+		t.Errorf("Stats before use: %+v", s)
+	}
+
+	re.MatchString("a") // not recorded
+	if re.stats.Load() != nil {
+		t.Error("stats should not be allocated while disabled")
+	}
+
+	defer EnableStats(EnableStats(true))
+	start := time.Now()
+	for i := 0; i < statsSampleRate+1; i++ {
+		re.MatchString("aaa")
+	}
+	re.Match([]byte("a"))
+	re.FindString("a")
+	re.FindAllStringIndex("aa", -1)
+	re.ReplaceAllString("a", "b")
+	re.Split("bab", -1)
+
+	// methods outside of reonce.go
+	re.FindMatch("a")
+	re.FindAllMatches("aa", -1)
+	if err := re.Unmarshal("a", &struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	err := re.FindAllReader(strings.NewReader("a"), 0, func([]byte, []int64) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(re.NewReplaceReader(strings.NewReader("a"), []byte("b"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := re.NewReplaceLiteralWriter(io.Discard, []byte("b")).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := re.Stats()
+	if !s.Compiled || s.Err != nil || s.CompiledAt.IsZero() || s.CompiledAt.After(start) {
+		t.Errorf("Stats: unexpected compile state: %+v", s)
+	}
+	if s.File == "" || s.Line == 0 || s.Expr != `a+` {
+		t.Errorf("Stats: unexpected declaration: %+v", s)
+	}
+	if s.FirstUse.Before(start) || s.FirstUse.After(time.Now()) {
+		t.Errorf("Stats: FirstUse = %v want a time after %v", s.FirstUse, start)
+	}
+	if s.MatchCalls != statsSampleRate+2 || s.FindCalls != 6 || s.ReplaceCalls != 3 || s.SplitCalls != 1 {
+		t.Errorf("Stats: unexpected call counts: %+v", s)
+	}
+	if s.Calls() != statsSampleRate+12 {
+		t.Errorf("Calls() = %d want: %d", s.Calls(), statsSampleRate+12)
+	}
+	// the first and 65th match and the first call of the other families
+	if s.Samples != 5 || s.SampledTime < 0 || s.MeanLatency() != s.SampledTime/5 {
+		t.Errorf("Stats: unexpected samples: %d in %v", s.Samples, s.SampledTime)
+	}
+}
+
+func TestAllStats(t *testing.T) {
+	re1 := New("a")
+	re2 := New("(")
+	withRegistry(t, re1, re2)
+	re1.MustCompile()
+	re2.Compile()
+
+	stats := AllStats()
+	if len(stats) != 2 || stats[0].Expr != "a" || stats[1].Expr != "(" {
+		t.Fatalf("AllStats: %+v", stats)
+	}
+	if stats[1].Err != re2.Err() || !stats[1].Compiled {
+		t.Errorf("AllStats: %+v", stats[1])
+	}
+}
@@ -0,0 +1,247 @@
+package reonce
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"unicode/utf8"
+)
+
+// DefaultMaxMatchLen is the maximum match length used by FindAllReader when
+// a maxLen of zero or less is given, and by the replacing readers and
+// writers.
+const DefaultMaxMatchLen = 64 * 1024
+
+// ErrMatchTooLong is returned by FindAllReader and the replacing readers and
+// writers when they find a match longer than the maximum match length.
+var ErrMatchTooLong = errors.New("reonce: match exceeds maximum match length")
+
+// minReadSize is the minimum number of bytes read from the io.Reader at a
+// time by FindAllReader. It is a variable so that tests can cross buffer
+// boundaries with small inputs.
+var minReadSize = 32 * 1024
+
+// A streamScanner finds successive matches in a stream of text that is held
+// in a bounded buffer. Text is added to the end of the buffer and text that
+// is no longer needed to find the next match is discarded from the start.
+type streamScanner struct {
+	rx  *regexp.Regexp
+	m   *offsetMatcher
+	sub bool
+
+	maxLen int
+	// window is the amount of text that must follow the start of a match
+	// for it to be final: maxLen bytes for the match, plus a rune of
+	// lookahead for assertions at the end of the match.
+	window int
+
+	buf          []byte
+	base         int64 // offset of buf[0] in the stream
+	pos          int   // search position in buf
+	prevMatchEnd int   // end of previous match in buf
+	eof          bool  // all of the text has been added to buf
+	empty        int   // number of consecutive empty reads
+}
+
+// newStreamScanner returns a streamScanner for rx, the compiled Regexp of
+// re. If sub is true the submatches are found as well.
+func (re *Regexp) newStreamScanner(rx *regexp.Regexp, maxLen int, sub bool) *streamScanner {
+	if maxLen <= 0 {
+		maxLen = DefaultMaxMatchLen
+	}
+	var m *offsetMatcher
+	if re.err != nil {
+		m = &offsetMatcher{} // rx never matches
+	} else {
+		m = re.offsetMatcher()
+	}
+	window := maxLen + utf8.UTFMax
+	return &streamScanner{
+		rx:           rx,
+		m:            m,
+		sub:          sub && rx.NumSubexp() > 0,
+		maxLen:       maxLen,
+		window:       window,
+		buf:          make([]byte, 0, 2*window+minReadSize),
+		prevMatchEnd: -1,
+	}
+}
+
+// discardable returns the number of bytes at the start of buf that are not
+// required to find the next match.
+func (s *streamScanner) discardable() int {
+	// Keep a rune of context before pos, which is also required so that
+	// pos is not mistaken for the start of the text.
+	return max(s.pos-utf8.UTFMax, 0)
+}
+
+// discard removes the first n bytes of buf, n must not be greater than
+// discardable.
+func (s *streamScanner) discard(n int) {
+	if n > 0 {
+		s.buf = s.buf[:copy(s.buf, s.buf[n:])]
+		s.base += int64(n)
+		s.pos -= n
+		s.prevMatchEnd -= n
+	}
+}
+
+// full reports if there is no room in buf for more text.
+func (s *streamScanner) full() bool { return len(s.buf) == cap(s.buf) }
+
+// compact discards the text that is not required to find the next match
+// and makes sure there is room in buf for more text.
+func (s *streamScanner) compact() {
+	s.discard(s.discardable())
+	if s.full() {
+		s.buf = append(s.buf, 0)[:len(s.buf)]
+	}
+}
+
+// write appends as much of p as fits to buf and returns the number of
+// bytes appended.
+func (s *streamScanner) write(p []byte) int {
+	n := copy(s.buf[len(s.buf):cap(s.buf)], p)
+	s.buf = s.buf[:len(s.buf)+n]
+	return n
+}
+
+// readFrom compacts buf and reads from r until buf is full or EOF.
+func (s *streamScanner) readFrom(r io.Reader) error {
+	s.compact()
+	for !s.full() {
+		n, err := r.Read(s.buf[len(s.buf):cap(s.buf)])
+		s.buf = s.buf[:len(s.buf)+n]
+		if err == io.EOF {
+			s.eof = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			if s.empty++; s.empty >= 100 {
+				return io.ErrNoProgress
+			}
+		} else {
+			s.empty = 0
+		}
+	}
+	return nil
+}
+
+// next returns the index of the next match in buf, with submatches if sub
+// is true. It returns nil if there are no more matches or, if not at EOF,
+// more text is required to know the next match.
+func (s *streamScanner) next() ([]int, error) {
+	for s.pos <= len(s.buf) {
+		loc := s.m.findAt(s.rx, s.buf, "", s.pos, s.sub)
+		if !s.eof && (loc == nil || loc[0]+s.window > len(s.buf)) {
+			// The match is not final since the text that follows it has not
+			// been read. No match starts before len(buf)-window, so advance
+			// pos to the first rune at or after it.
+			for safe := len(s.buf) - s.window; s.pos < safe; {
+				_, w := utf8.DecodeRune(s.buf[s.pos:])
+				s.pos += w
+			}
+			return nil, nil
+		}
+		if loc == nil {
+			break
+		}
+		if loc[1]-loc[0] > s.maxLen {
+			return nil, ErrMatchTooLong
+		}
+
+		// Same as allMatches.
+		accept := true
+		if loc[1] == s.pos {
+			// We've found an empty match.
+			if loc[0] == s.prevMatchEnd {
+				// We don't allow an empty match right
+				// after a previous match, so ignore it.
+				accept = false
+			}
+			if _, width := utf8.DecodeRune(s.buf[s.pos:]); width > 0 {
+				s.pos += width
+			} else {
+				s.pos = len(s.buf) + 1
+			}
+		} else {
+			s.pos = loc[1]
+		}
+		s.prevMatchEnd = loc[1]
+
+		if accept {
+			return loc, nil
+		}
+	}
+	s.pos = len(s.buf) + 1
+	return nil, nil
+}
+
+// done reports if all of the matches have been found.
+func (s *streamScanner) done() bool { return s.eof && s.pos > len(s.buf) }
+
+// FindAllReader reads r until EOF and calls fn with each successive match
+// of the expression. The matches are the same as those returned by
+// FindAllSubmatchIndex if it were called with all of the text read from r,
+// but only a bounded amount of the text is held in memory.
+//
+// The argument match is the text of the match and loc holds the absolute
+// byte offsets of the match and its submatches in the stream, as defined by
+// the 'Submatch' and 'Index' descriptions in the package comment of the
+// regexp package. The match slice is only valid until fn returns and loc
+// must not be retained. If fn returns an error, reading stops and
+// FindAllReader returns that error.
+//
+// Matches may span the boundaries of reads from r. To bound memory use the
+// expression must not match more than maxLen bytes: results are exact for
+// such expressions and FindAllReader holds at most a few times maxLen bytes
+// of text at once. If a match longer than maxLen bytes is found
+// FindAllReader stops and returns ErrMatchTooLong. An expression that can
+// match an unbounded amount of text, such as `a.*`, may also be reported
+// as too long when anchored at the end of the text (`$` or `\z`), since the
+// end of the text read so far cannot be distinguished from the end of the
+// stream. If maxLen is zero or less DefaultMaxMatchLen is used.
+//
+// FindAllReader returns the *CompileError of a Regexp that fails to compile
+// instead of using the ErrorHandler, and any error other than io.EOF
+// returned by r.
+func (re *Regexp) FindAllReader(r io.Reader, maxLen int, fn func(match []byte, loc []int64) error) error {
+	re.once.Do(re.init)
+	if re.err != nil {
+		return re.err
+	}
+	if statsEnabled.Load() {
+		re.record(familyFind)
+	}
+	s := re.newStreamScanner(re.rx, maxLen, true)
+	var loc64 []int64
+	for !s.done() {
+		loc, err := s.next()
+		if err != nil {
+			return err
+		}
+		if loc == nil {
+			if !s.eof {
+				if err := s.readFrom(r); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		loc64 = loc64[:0]
+		for _, x := range loc {
+			if x >= 0 {
+				loc64 = append(loc64, s.base+int64(x))
+			} else {
+				loc64 = append(loc64, -1)
+			}
+		}
+		if err := fn(s.buf[loc[0]:loc[1]:loc[1]], loc64); err != nil {
+			return err
+		}
+	}
+	return nil
+}
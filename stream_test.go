@@ -0,0 +1,136 @@
+//go:build go1.23 && !reoncetest
+
+package reonce
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// findAllReader returns the submatch indexes of all of the matches found by
+// FindAllReader and checks that the match text is correct.
+func findAllReader(t *testing.T, re *Regexp, r io.Reader, b []byte, maxLen int) ([][]int, error) {
+	t.Helper()
+	var locs [][]int
+	err := re.FindAllReader(r, maxLen, func(match []byte, loc64 []int64) error {
+		loc := make([]int, len(loc64))
+		for i, x := range loc64 {
+			loc[i] = int(x)
+		}
+		if !bytes.Equal(match, b[loc[0]:loc[1]]) {
+			t.Errorf("%s: match %q does not equal input[%d:%d] = %q",
+				re, match, loc[0], loc[1], b[loc[0]:loc[1]])
+		}
+		locs = append(locs, loc)
+		return nil
+	})
+	return locs, err
+}
+
+// chunkReader returns at most n bytes per call to Read.
+type chunkReader struct {
+	r *bytes.Reader
+	n int
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(p) > c.n {
+		p = p[:c.n]
+	}
+	return c.r.Read(p)
+}
+
+func TestFindAllReader(t *testing.T) {
+	res, rxs := testRegexps()
+	for i, re := range res {
+		rx := rxs[i]
+		for _, s := range matchTests.inputs {
+			b := []byte(s)
+			want := rx.FindAllSubmatchIndex(b, -1)
+			got, err := findAllReader(t, re, iotest.OneByteReader(bytes.NewReader(b)), b, 0)
+			if err != nil {
+				t.Fatalf("%s: FindAllReader(%q): %v", re, s, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s (posix=%t longest=%t): FindAllReader(%q):\ngot:  %v\nwant: %v",
+					re, re.opts.POSIX, re.opts.Longest, s, got, want)
+			}
+		}
+	}
+}
+
+// Test matches that span the boundaries of the buffer used by FindAllReader.
+func TestFindAllReaderBoundaries(t *testing.T) {
+	const maxLen = 32
+	defer func(n int) { minReadSize = n }(minReadSize)
+	minReadSize = 16
+
+	var input []byte
+	for len(input) < 1024 {
+		input = append(input, strings.Join(matchTests.inputs, "\n")...)
+		input = append(input, '\n')
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	res, rxs := testRegexps()
+	for i, re := range res {
+		want := rxs[i].FindAllSubmatchIndex(input, -1)
+		n := r.Intn(2*maxLen) + 1 // size of reads
+		got, err := findAllReader(t, re, &chunkReader{bytes.NewReader(input), n}, input, maxLen)
+		if err != nil {
+			t.Fatalf("%s: FindAllReader: %v", re, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s (posix=%t longest=%t): FindAllReader (read size %d): got %d matches want: %d",
+				re, re.opts.POSIX, re.opts.Longest, n, len(got), len(want))
+		}
+	}
+}
+
+func TestFindAllReaderMatchTooLong(t *testing.T) {
+	re := New(`a+`)
+	input := "b" + strings.Repeat("a", 100) + "b"
+	_, err := findAllReader(t, re, strings.NewReader(input), []byte(input), 99)
+	if err != ErrMatchTooLong {
+		t.Errorf("FindAllReader: got: %v want: %v", err, ErrMatchTooLong)
+	}
+	locs, err := findAllReader(t, re, strings.NewReader(input), []byte(input), 100)
+	if err != nil || !reflect.DeepEqual(locs, [][]int{{1, 101}}) {
+		t.Errorf("FindAllReader = %v, %v want: %v", locs, err, [][]int{{1, 101}})
+	}
+}
+
+func TestFindAllReaderErrors(t *testing.T) {
+	errStop := errors.New("stop")
+	var calls int
+	err := New(`a`).FindAllReader(strings.NewReader("aaa"), 0, func([]byte, []int64) error {
+		calls++
+		return errStop
+	})
+	if err != errStop || calls != 1 {
+		t.Errorf("FindAllReader = %v after %d calls want: %v after 1 call", err, calls, errStop)
+	}
+
+	errRead := errors.New("read error")
+	r := io.MultiReader(strings.NewReader("aaa"), iotest.ErrReader(errRead))
+	if err := New(`a`).FindAllReader(r, 0, func([]byte, []int64) error { return nil }); err != errRead {
+		t.Errorf("FindAllReader: got: %v want: %v", err, errRead)
+	}
+
+	re := NewWithOptions(`(`, Options{OnError: PanicOnError})
+	err = re.FindAllReader(strings.NewReader("("), 0, func([]byte, []int64) error {
+		t.Error("unexpected match")
+		return nil
+	})
+	var cerr *CompileError
+	if !errors.As(err, &cerr) {
+		t.Errorf("FindAllReader: expected a *CompileError got: %v", err)
+	}
+}
@@ -0,0 +1,363 @@
+package reonce
+
+import (
+	"io"
+	"regexp"
+)
+
+// TryRegexp is a view of a Regexp with methods that return the compile error
+// of the Regexp instead of panicking. It allows lazily compiled Regexps to be
+// used with patterns that may be invalid, such as patterns read from
+// configuration files. The methods of TryRegexp mirror those of
+// regexp.Regexp and return a *CompileError if the Regexp is invalid.
+type TryRegexp struct {
+	re *Regexp
+}
+
+// Try returns a view of re with methods that return the compile error of re,
+// if any, instead of panicking.
+func (re *Regexp) Try() TryRegexp { return TryRegexp{re: re} }
+
+// rx compiles and returns the regexp.Regexp or the compile error.
+func (t TryRegexp) rx() (*regexp.Regexp, error) {
+	t.re.once.Do(t.re.init)
+	return t.re.rx, t.re.err
+}
+
+// Regexp returns the underlying *regexp.Regexp.
+func (t TryRegexp) Regexp() (*regexp.Regexp, error) {
+	return t.rx()
+}
+
+// String returns the source text used to compile the regular expression.
+func (t TryRegexp) String() string { return t.re.expr }
+
+// Copy is like Regexp.Copy but returns an error if the Regexp is invalid.
+func (t TryRegexp) Copy() (*regexp.Regexp, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.Copy(), nil
+}
+
+// Expand is like Regexp.Expand but returns an error if the Regexp is invalid.
+func (t TryRegexp) Expand(dst []byte, template []byte, src []byte, match []int) ([]byte, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.Expand(dst, template, src, match), nil
+}
+
+// ExpandString is like Regexp.ExpandString but returns an error if the Regexp is invalid.
+func (t TryRegexp) ExpandString(dst []byte, template string, src string, match []int) ([]byte, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.ExpandString(dst, template, src, match), nil
+}
+
+// Find is like Regexp.Find but returns an error if the Regexp is invalid.
+func (t TryRegexp) Find(b []byte) ([]byte, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.Find(b), nil
+}
+
+// FindAll is like Regexp.FindAll but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindAll(b []byte, n int) ([][]byte, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindAll(b, n), nil
+}
+
+// FindAllIndex is like Regexp.FindAllIndex but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindAllIndex(b []byte, n int) ([][]int, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindAllIndex(b, n), nil
+}
+
+// FindAllString is like Regexp.FindAllString but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindAllString(s string, n int) ([]string, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindAllString(s, n), nil
+}
+
+// FindAllStringIndex is like Regexp.FindAllStringIndex but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindAllStringIndex(s string, n int) ([][]int, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindAllStringIndex(s, n), nil
+}
+
+// FindAllStringSubmatch is like Regexp.FindAllStringSubmatch but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindAllStringSubmatch(s string, n int) ([][]string, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindAllStringSubmatch(s, n), nil
+}
+
+// FindAllStringSubmatchIndex is like Regexp.FindAllStringSubmatchIndex but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindAllStringSubmatchIndex(s string, n int) ([][]int, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindAllStringSubmatchIndex(s, n), nil
+}
+
+// FindAllSubmatch is like Regexp.FindAllSubmatch but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindAllSubmatch(b []byte, n int) ([][][]byte, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindAllSubmatch(b, n), nil
+}
+
+// FindAllSubmatchIndex is like Regexp.FindAllSubmatchIndex but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindAllSubmatchIndex(b []byte, n int) ([][]int, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindAllSubmatchIndex(b, n), nil
+}
+
+// FindIndex is like Regexp.FindIndex but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindIndex(b []byte) (loc []int, err error) {
+	rx, err := t.rx()
+	if err != nil {
+		return
+	}
+	loc = rx.FindIndex(b)
+	return
+}
+
+// FindReaderIndex is like Regexp.FindReaderIndex but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindReaderIndex(r io.RuneReader) (loc []int, err error) {
+	rx, err := t.rx()
+	if err != nil {
+		return
+	}
+	loc = rx.FindReaderIndex(r)
+	return
+}
+
+// FindReaderSubmatchIndex is like Regexp.FindReaderSubmatchIndex but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindReaderSubmatchIndex(r io.RuneReader) ([]int, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindReaderSubmatchIndex(r), nil
+}
+
+// FindString is like Regexp.FindString but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindString(s string) (string, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return "", err
+	}
+	return rx.FindString(s), nil
+}
+
+// FindStringIndex is like Regexp.FindStringIndex but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindStringIndex(s string) (loc []int, err error) {
+	rx, err := t.rx()
+	if err != nil {
+		return
+	}
+	loc = rx.FindStringIndex(s)
+	return
+}
+
+// FindStringSubmatch is like Regexp.FindStringSubmatch but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindStringSubmatch(s string) ([]string, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindStringSubmatch(s), nil
+}
+
+// FindStringSubmatchIndex is like Regexp.FindStringSubmatchIndex but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindStringSubmatchIndex(s string) ([]int, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindStringSubmatchIndex(s), nil
+}
+
+// FindSubmatch is like Regexp.FindSubmatch but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindSubmatch(b []byte) ([][]byte, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindSubmatch(b), nil
+}
+
+// FindSubmatchIndex is like Regexp.FindSubmatchIndex but returns an error if the Regexp is invalid.
+func (t TryRegexp) FindSubmatchIndex(b []byte) ([]int, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.FindSubmatchIndex(b), nil
+}
+
+// LiteralPrefix is like Regexp.LiteralPrefix but returns an error if the Regexp is invalid.
+func (t TryRegexp) LiteralPrefix() (prefix string, complete bool, err error) {
+	rx, err := t.rx()
+	if err != nil {
+		return
+	}
+	prefix, complete = rx.LiteralPrefix()
+	return
+}
+
+// Longest is like Regexp.Longest but returns an error if the Regexp is invalid.
+// Like Regexp.Longest it may not be called concurrently with any other methods.
+func (t TryRegexp) Longest() error {
+	rx, err := t.rx()
+	if err != nil {
+		return err
+	}
+	t.re.setLongest(rx)
+	return nil
+}
+
+// Match is like Regexp.Match but returns an error if the Regexp is invalid.
+func (t TryRegexp) Match(b []byte) (bool, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return false, err
+	}
+	return rx.Match(b), nil
+}
+
+// MatchReader is like Regexp.MatchReader but returns an error if the Regexp is invalid.
+func (t TryRegexp) MatchReader(r io.RuneReader) (bool, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return false, err
+	}
+	return rx.MatchReader(r), nil
+}
+
+// MatchString is like Regexp.MatchString but returns an error if the Regexp is invalid.
+func (t TryRegexp) MatchString(s string) (bool, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return false, err
+	}
+	return rx.MatchString(s), nil
+}
+
+// NumSubexp is like Regexp.NumSubexp but returns an error if the Regexp is invalid.
+func (t TryRegexp) NumSubexp() (int, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return 0, err
+	}
+	return rx.NumSubexp(), nil
+}
+
+// ReplaceAll is like Regexp.ReplaceAll but returns an error if the Regexp is invalid.
+func (t TryRegexp) ReplaceAll(src, repl []byte) ([]byte, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.ReplaceAll(src, repl), nil
+}
+
+// ReplaceAllFunc is like Regexp.ReplaceAllFunc but returns an error if the Regexp is invalid.
+func (t TryRegexp) ReplaceAllFunc(src []byte, repl func([]byte) []byte) ([]byte, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.ReplaceAllFunc(src, repl), nil
+}
+
+// ReplaceAllLiteral is like Regexp.ReplaceAllLiteral but returns an error if the Regexp is invalid.
+func (t TryRegexp) ReplaceAllLiteral(src, repl []byte) ([]byte, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.ReplaceAllLiteral(src, repl), nil
+}
+
+// ReplaceAllLiteralString is like Regexp.ReplaceAllLiteralString but returns an error if the Regexp is invalid.
+func (t TryRegexp) ReplaceAllLiteralString(src, repl string) (string, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return "", err
+	}
+	return rx.ReplaceAllLiteralString(src, repl), nil
+}
+
+// ReplaceAllString is like Regexp.ReplaceAllString but returns an error if the Regexp is invalid.
+func (t TryRegexp) ReplaceAllString(src, repl string) (string, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return "", err
+	}
+	return rx.ReplaceAllString(src, repl), nil
+}
+
+// ReplaceAllStringFunc is like Regexp.ReplaceAllStringFunc but returns an error if the Regexp is invalid.
+func (t TryRegexp) ReplaceAllStringFunc(src string, repl func(string) string) (string, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return "", err
+	}
+	return rx.ReplaceAllStringFunc(src, repl), nil
+}
+
+// Split is like Regexp.Split but returns an error if the Regexp is invalid.
+func (t TryRegexp) Split(s string, n int) ([]string, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.Split(s, n), nil
+}
+
+// SubexpNames is like Regexp.SubexpNames but returns an error if the Regexp is invalid.
+func (t TryRegexp) SubexpNames() ([]string, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return nil, err
+	}
+	return rx.SubexpNames(), nil
+}
+
+// SubexpIndex is like Regexp.SubexpIndex but returns an error if the Regexp is invalid.
+func (t TryRegexp) SubexpIndex(name string) (int, error) {
+	rx, err := t.rx()
+	if err != nil {
+		return -1, err
+	}
+	return rx.SubexpIndex(name), nil
+}
@@ -0,0 +1,81 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTryRegexp(t *testing.T) {
+	typ := reflect.TypeOf(TryRegexp{})
+	for i := 0; i < typ.NumMethod(); i++ {
+		m := typ.Method(i)
+		t.Run(m.Name, func(t *testing.T) {
+			good := New(`(a+)(?P<name>b*)`)
+			try := reflect.ValueOf(good.Try()).MethodByName(m.Name)
+			got := try.Call(buildMethodArgs(t, try))
+
+			if m.Name == "String" {
+				if s := got[0].String(); s != good.String() {
+					t.Errorf("String() = %q want: %q", s, good.String())
+				}
+				return
+			}
+			if err := got[len(got)-1]; !err.IsNil() {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got = got[:len(got)-1]
+
+			// compare results against the Regexp method of the same name
+			meth := reflect.ValueOf(good).MethodByName(m.Name)
+			if !meth.IsValid() {
+				t.Fatalf("Regexp does not have method: %s", m.Name)
+			}
+			want := meth.Call(buildMethodArgs(t, meth))
+			if len(got) != len(want) {
+				t.Fatalf("got %d results want: %d", len(got), len(want))
+			}
+			switch m.Name {
+			case "Copy", "Regexp":
+				return // pointers differ
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i].Interface(), want[i].Interface()) {
+					t.Errorf("result %d: got: %#v want: %#v", i, got[i].Interface(), want[i].Interface())
+				}
+			}
+		})
+		t.Run(m.Name+"_Error", func(t *testing.T) {
+			bad := New(`(a`)
+			defer func() {
+				if e := recover(); e != nil {
+					t.Fatalf("panic: %v", e)
+				}
+			}()
+			try := reflect.ValueOf(bad.Try()).MethodByName(m.Name)
+			got := try.Call(buildMethodArgs(t, try))
+			if m.Name == "String" {
+				return
+			}
+			err, _ := got[len(got)-1].Interface().(error)
+			var cerr *CompileError
+			if !errors.As(err, &cerr) || cerr != bad.Err() {
+				t.Errorf("expected the compile error of the Regexp got: %v", err)
+			}
+		})
+	}
+}
+
+func TestTryNoPanic(t *testing.T) {
+	re := New("*")
+	ok, err := re.Try().MatchString("a")
+	if ok || err == nil {
+		t.Errorf("MatchString = %t, %v want: false, error", ok, err)
+	}
+	if i, err := re.Try().SubexpIndex("a"); i != -1 || err == nil {
+		t.Errorf("SubexpIndex = %d, %v want: -1, error", i, err)
+	}
+}
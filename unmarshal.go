@@ -0,0 +1,309 @@
+package reonce
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNoMatch is returned by Unmarshal when the Regexp does not match the
+// input.
+var ErrNoMatch = errors.New("reonce: no match")
+
+// ErrMissingGroup is the Err of an UnmarshalError for a struct field whose
+// tag names a capture group that does not exist in the Regexp.
+var ErrMissingGroup = errors.New("no such group")
+
+// An UnmarshalError describes a capture group that could not be stored in a
+// struct field by Unmarshal.
+type UnmarshalError struct {
+	Expr  string       // source text of the Regexp
+	Group string       // name of the capture group
+	Value string       // text of the capture group
+	Field string       // name of the struct field, including the struct type
+	Type  reflect.Type // type of the struct field
+	Err   error        // ErrMissingGroup or the conversion error
+}
+
+func (e *UnmarshalError) Error() string {
+	if e.Err == ErrMissingGroup {
+		return "reonce: regexp " + quote(e.Expr) + " has no group " +
+			strconv.Quote(e.Group) + " for field " + e.Field
+	}
+	return "reonce: cannot unmarshal group " + strconv.Quote(e.Group) +
+		" value " + strconv.Quote(e.Value) + " into field " + e.Field +
+		" of type " + e.Type.String() + ": " + e.Err.Error()
+}
+
+func (e *UnmarshalError) Unwrap() error { return e.Err }
+
+// Unmarshal finds the leftmost match of the Regexp in s and stores the text
+// of its named capture groups in the struct pointed to by v. Each struct
+// field with a tag of the form `re:"name"` is set from the capture group
+// with that name. Untagged and unexported fields, and fields tagged
+// `re:"-"`, are ignored. Fields of embedded structs are handled as if they
+// were fields of the outer struct.
+//
+// The following field types are supported, as well as pointers to them,
+// which are allocated as needed:
+//
+//   - string and []byte
+//   - signed and unsigned integers, parsed in base 10
+//   - floating point numbers and bools, parsed with the strconv package
+//   - time.Duration, parsed with time.ParseDuration
+//   - time.Time, parsed using the layout given by the field's `layout` tag
+//     or time.RFC3339 if it has none
+//   - types that implement encoding.TextUnmarshaler
+//
+// Fields for capture groups that did not participate in the match are left
+// unchanged. If the Regexp does not match s, Unmarshal returns an error
+// that wraps ErrNoMatch and v is not modified. If a tag names a group that
+// does not exist or the text of a group cannot be converted to the type of
+// its field, an *UnmarshalError is returned. Fields are set in order and
+// the fields before the failing one are modified.
+//
+// Unmarshal returns the *CompileError of a Regexp that fails to compile
+// instead of using the ErrorHandler.
+//
+//	var line struct {
+//		Level string        `re:"level"`
+//		Took  time.Duration `re:"took"`
+//		Time  time.Time     `re:"ts" layout:"2006-01-02 15:04:05"`
+//	}
+//	err := re.Unmarshal(s, &line)
+func (re *Regexp) Unmarshal(s string, v any) error {
+	re.once.Do(re.init)
+	if re.err != nil {
+		return re.err
+	}
+	if statsEnabled.Load() {
+		defer re.observe(familyFind)()
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("reonce: Unmarshal requires a non-nil pointer to a struct: %T", v)
+	}
+	fields, err := cachedFields(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	// Check all groups exist before matching so that errors in the struct
+	// definition are reported regardless of the input.
+	groups := make([]int, len(fields))
+	for i, f := range fields {
+		groups[i] = re.rx.SubexpIndex(f.group)
+		if groups[i] < 0 {
+			return &UnmarshalError{Expr: re.expr, Group: f.group, Field: f.name,
+				Type: f.typ, Err: ErrMissingGroup}
+		}
+	}
+	loc := re.rx.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return fmt.Errorf("%w: regexp %s in %q", ErrNoMatch, quote(re.expr), s)
+	}
+	rv = rv.Elem()
+	for i, f := range fields {
+		start, end := loc[2*groups[i]], loc[2*groups[i]+1]
+		if start < 0 {
+			continue
+		}
+		if err := f.decode(fieldByIndex(rv, f.index), s[start:end]); err != nil {
+			return &UnmarshalError{Expr: re.expr, Group: f.group, Value: s[start:end],
+				Field: f.name, Type: f.typ, Err: err}
+		}
+	}
+	return nil
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but allocates nil
+// pointers to embedded structs.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// A decodeFunc stores the text s in v.
+type decodeFunc func(v reflect.Value, s string) error
+
+// structField is a struct field that is set from a capture group.
+type structField struct {
+	group  string // name of the capture group
+	name   string // Type.Field
+	index  []int
+	typ    reflect.Type
+	decode decodeFunc
+}
+
+// structFields are the fields of a struct type or the error describing why
+// it cannot be used with Unmarshal.
+type structFields struct {
+	fields []structField
+	err    error
+}
+
+var fieldCache sync.Map // reflect.Type => *structFields
+
+// cachedFields returns the fields of struct type t that are set by
+// Unmarshal.
+func cachedFields(t reflect.Type) ([]structField, error) {
+	if f, ok := fieldCache.Load(t); ok {
+		sf := f.(*structFields)
+		return sf.fields, sf.err
+	}
+	fields, err := typeFields(t)
+	f, _ := fieldCache.LoadOrStore(t, &structFields{fields: fields, err: err})
+	sf := f.(*structFields)
+	return sf.fields, sf.err
+}
+
+func typeFields(t reflect.Type) ([]structField, error) {
+	var fields []structField
+	for _, sf := range reflect.VisibleFields(t) {
+		tag, ok := sf.Tag.Lookup("re")
+		if !ok || tag == "-" || !sf.IsExported() || !settable(t, sf.Index) {
+			continue
+		}
+		name := t.String() + "." + sf.Name
+		if tag == "" {
+			return nil, fmt.Errorf("reonce: empty re tag on field %s", name)
+		}
+		layout := sf.Tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		decode, err := decoder(sf.Type, layout)
+		if err != nil {
+			return nil, fmt.Errorf("reonce: cannot unmarshal into field %s: %w", name, err)
+		}
+		fields = append(fields, structField{
+			group:  tag,
+			name:   name,
+			index:  sf.Index,
+			typ:    sf.Type,
+			decode: decode,
+		})
+	}
+	return fields, nil
+}
+
+// settable reports if the field of t at index can be set, which is not the
+// case for fields promoted through unexported pointers to embedded structs
+// since they cannot be allocated.
+func settable(t reflect.Type, index []int) bool {
+	for _, x := range index[:len(index)-1] {
+		f := t.Field(x)
+		t = f.Type
+		if t.Kind() == reflect.Pointer {
+			if !f.IsExported() {
+				return false
+			}
+			t = t.Elem()
+		}
+	}
+	return true
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decoder returns the decodeFunc for values of type t.
+func decoder(t reflect.Type, layout string) (decodeFunc, error) {
+	switch t {
+	case durationType:
+		return func(v reflect.Value, s string) error {
+			d, err := time.ParseDuration(s)
+			if err == nil {
+				v.SetInt(int64(d))
+			}
+			return err
+		}, nil
+	case timeType:
+		return func(v reflect.Value, s string) error {
+			tm, err := time.Parse(layout, s)
+			if err == nil {
+				v.Set(reflect.ValueOf(tm))
+			}
+			return err
+		}, nil
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return func(v reflect.Value, s string) error {
+			return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		}, nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return func(v reflect.Value, s string) error {
+			v.SetString(s)
+			return nil
+		}, nil
+	case reflect.Bool:
+		return func(v reflect.Value, s string) error {
+			b, err := strconv.ParseBool(s)
+			if err == nil {
+				v.SetBool(b)
+			}
+			return err
+		}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value, s string) error {
+			n, err := strconv.ParseInt(s, 10, t.Bits())
+			if err == nil {
+				v.SetInt(n)
+			}
+			return err
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(v reflect.Value, s string) error {
+			n, err := strconv.ParseUint(s, 10, t.Bits())
+			if err == nil {
+				v.SetUint(n)
+			}
+			return err
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value, s string) error {
+			f, err := strconv.ParseFloat(s, t.Bits())
+			if err == nil {
+				v.SetFloat(f)
+			}
+			return err
+		}, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return func(v reflect.Value, s string) error {
+				v.SetBytes(append(make([]byte, 0, len(s)), s...))
+				return nil
+			}, nil
+		}
+	case reflect.Pointer:
+		elem, err := decoder(t.Elem(), layout)
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value, s string) error {
+			p := reflect.New(t.Elem())
+			if err := elem(p.Elem(), s); err != nil {
+				return err
+			}
+			v.Set(p)
+			return nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
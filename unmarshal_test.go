@@ -0,0 +1,184 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type logLine struct {
+	Time    time.Time     `re:"ts" layout:"2006-01-02 15:04:05"`
+	Level   string        `re:"level"`
+	Code    int           `re:"code"`
+	Size    uint16        `re:"size"`
+	Ratio   float64       `re:"ratio"`
+	OK      bool          `re:"ok"`
+	Took    time.Duration `re:"took"`
+	Addr    netip.Addr    `re:"addr"`
+	Msg     []byte        `re:"msg"`
+	User    *string       `re:"user"`
+	Ignored string
+	skipped string `re:"level"`
+}
+
+const logExpr = `^(?P<ts>\S+ \S+) (?P<level>\w+) code=(?P<code>-?\d+) size=(?P<size>\d+) ` +
+	`ratio=(?P<ratio>\S+) ok=(?P<ok>\w+) took=(?P<took>\S+) addr=(?P<addr>\S+)` +
+	`(?: user=(?P<user>\w+))? msg=(?P<msg>.*)$`
+
+func TestUnmarshal(t *testing.T) {
+	re := New(logExpr)
+	s := "2024-01-02 03:04:05 INFO code=-42 size=512 ratio=0.5 ok=true took=1.5s " +
+		"addr=10.0.0.1 user=bob msg=hello world"
+
+	var got logLine
+	if err := re.Unmarshal(s, &got); err != nil {
+		t.Fatal(err)
+	}
+	user := "bob"
+	want := logLine{
+		Time:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level: "INFO",
+		Code:  -42,
+		Size:  512,
+		Ratio: 0.5,
+		OK:    true,
+		Took:  1500 * time.Millisecond,
+		Addr:  netip.MustParseAddr("10.0.0.1"),
+		Msg:   []byte("hello world"),
+		User:  &user,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal:\ngot:  %+v\nwant: %+v", got, want)
+	}
+
+	// Groups that do not participate leave the field unchanged.
+	prev := "alice"
+	got = logLine{User: &prev}
+	s = "2024-01-02 03:04:05 INFO code=1 size=1 ratio=1 ok=false took=1s addr=::1 msg="
+	if err := re.Unmarshal(s, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.User != &prev || prev != "alice" {
+		t.Errorf("User = %v want: %v", got.User, &prev)
+	}
+}
+
+func TestUnmarshalEmbedded(t *testing.T) {
+	type Inner struct {
+		Key string `re:"key"`
+	}
+	type Outer struct {
+		*Inner
+		Value int `re:"value"`
+	}
+	var v Outer
+	if err := New(`(?P<key>\w+)=(?P<value>\d+)`).Unmarshal("a=1", &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Inner == nil || v.Key != "a" || v.Value != 1 {
+		t.Errorf("Unmarshal = %+v", v)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	re := New(`(?P<key>\w+)=(?P<value>\w+)`)
+
+	t.Run("NoMatch", func(t *testing.T) {
+		var v struct {
+			Key string `re:"key"`
+		}
+		if err := re.Unmarshal("abc", &v); !errors.Is(err, ErrNoMatch) {
+			t.Errorf("got error: %v want: %v", err, ErrNoMatch)
+		}
+	})
+
+	t.Run("MissingGroup", func(t *testing.T) {
+		type T struct {
+			Missing string `re:"missing"`
+		}
+		var v T
+		err := re.Unmarshal("a=b", &v)
+		var ue *UnmarshalError
+		if !errors.As(err, &ue) || !errors.Is(err, ErrMissingGroup) {
+			t.Fatalf("got error: %v want: *UnmarshalError", err)
+		}
+		if ue.Group != "missing" || ue.Field != "reonce.T.Missing" {
+			t.Errorf("unexpected error: %+v", ue)
+		}
+		const want = "reonce: regexp `(?P<key>\\w+)=(?P<value>\\w+)` has no group \"missing\" for field reonce.T.Missing"
+		if err.Error() != want {
+			t.Errorf("Error() = %q want: %q", err.Error(), want)
+		}
+	})
+
+	t.Run("Conversion", func(t *testing.T) {
+		type T struct {
+			Key   string `re:"key"`
+			Value int8   `re:"value"`
+		}
+		var v T
+		err := re.Unmarshal("a=300", &v)
+		var ue *UnmarshalError
+		if !errors.As(err, &ue) {
+			t.Fatalf("got error: %v want: *UnmarshalError", err)
+		}
+		if !errors.Is(err, strconv.ErrRange) {
+			t.Errorf("expected error to wrap %v: %v", strconv.ErrRange, err)
+		}
+		if ue.Group != "value" || ue.Value != "300" || ue.Type != reflect.TypeOf(int8(0)) {
+			t.Errorf("unexpected error: %+v", ue)
+		}
+		if v.Key != "a" {
+			t.Errorf("Key = %q want: %q", v.Key, "a")
+		}
+	})
+
+	t.Run("UnsupportedType", func(t *testing.T) {
+		var v struct {
+			Key chan int `re:"key"`
+		}
+		if err := re.Unmarshal("a=b", &v); err == nil {
+			t.Error("expected error for unsupported type")
+		}
+	})
+
+	t.Run("NotStructPointer", func(t *testing.T) {
+		var s string
+		for _, v := range []any{nil, s, &s, (*logLine)(nil)} {
+			if err := re.Unmarshal("a=b", v); err == nil {
+				t.Errorf("%T: expected error", v)
+			}
+		}
+	})
+
+	t.Run("CompileError", func(t *testing.T) {
+		var v struct{}
+		var ce *CompileError
+		if err := New("*").Unmarshal("a", &v); !errors.As(err, &ce) {
+			t.Errorf("got error: %v want: *CompileError", err)
+		}
+	})
+}
+
+func TestUnmarshalFieldCache(t *testing.T) {
+	type T struct {
+		A string `re:"a"`
+	}
+	re := New(`(?P<a>a)`)
+	var v T
+	if err := re.Unmarshal("a", &v); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fieldCache.Load(reflect.TypeOf(v)); !ok {
+		t.Error("field mapping was not cached")
+	}
+	if n := testing.AllocsPerRun(100, func() { re.Unmarshal("a", &v) }); n > 2 {
+		t.Errorf("allocs = %.0f want: <= 2", n)
+	}
+}
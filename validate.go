@@ -0,0 +1,68 @@
+package reonce
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ValidateAll compiles every registered Regexp (see Walk) and returns an
+// error joining the compile error of every invalid pattern, or nil if all
+// patterns are valid. It is safe to call ValidateAll concurrently with the
+// use of the Regexps it compiles.
+//
+// ValidateAll is intended to be called from tests or at startup (for
+// example, behind a --check-config flag) to catch invalid patterns without
+// requiring the reoncetest build tag.
+func ValidateAll() error {
+	var errs []error
+	for _, re := range registered() {
+		if err := re.Compile(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateAllParallel is like ValidateAll but compiles the registered
+// Regexps using up to n goroutines. If n <= 0, runtime.GOMAXPROCS(0) is
+// used. Errors are reported in the order the Regexps were created.
+func ValidateAllParallel(n int) error {
+	list := registered()
+	errs := make([]error, len(list))
+	compileParallel(context.Background(), list, n, func(i int, err error) {
+		errs[i] = err
+	})
+	return errors.Join(errs...)
+}
+
+// compileParallel compiles the Regexps in list using up to n goroutines and
+// calls done, which must be safe for concurrent use, with the index and
+// compile error of each Regexp. Compilation stops early if ctx is canceled
+// and done is not called for the Regexps that were skipped.
+func compileParallel(ctx context.Context, list []*Regexp, n int, done func(i int, err error)) {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	if n > len(list) {
+		n = len(list)
+	}
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(next.Add(1) - 1)
+				if i >= len(list) {
+					return
+				}
+				done(i, list[i].Compile())
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,76 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// withRegistry replaces the registry with list for the duration of the test.
+func withRegistry(t testing.TB, list ...*Regexp) {
+	registry.mu.Lock()
+	saved := registry.list
+	registry.list = list
+	registry.mu.Unlock()
+	t.Cleanup(func() {
+		registry.mu.Lock()
+		registry.list = saved
+		registry.mu.Unlock()
+	})
+}
+
+func testValidateAll(t *testing.T, validate func() error) {
+	good := New("a")
+	bad1 := New("*")
+	bad2 := NewPOSIX("[a")
+	withRegistry(t, good, bad1, New(`\d+`), bad2)
+
+	err := validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, re := range []*Regexp{bad1, bad2} {
+		if !errors.Is(err, re.Err()) {
+			t.Errorf("error does not contain the error for %q: %v", re.String(), err)
+		}
+	}
+	// errors are reported in creation order
+	msg := err.Error()
+	if i, j := strings.Index(msg, bad1.Err().Error()), strings.Index(msg, bad2.Err().Error()); i > j {
+		t.Errorf("errors not in creation order: %q", msg)
+	}
+	if !good.Compiled() {
+		t.Error("valid Regexp was not compiled")
+	}
+
+	withRegistry(t, New("a"), NewPOSIX("b"))
+	if err := validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	testValidateAll(t, ValidateAll)
+}
+
+func TestValidateAllParallel(t *testing.T) {
+	for _, n := range []int{-1, 0, 1, 2, 64} {
+		testValidateAll(t, func() error { return ValidateAllParallel(n) })
+	}
+}
+
+func TestCompileParallelCanceled(t *testing.T) {
+	list := []*Regexp{New("a"), New("b"), New("c")}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var n atomic.Int64
+	compileParallel(ctx, list, 2, func(int, error) { n.Add(1) })
+	if n.Load() != 0 {
+		t.Errorf("compiled %d Regexps after the context was canceled", n.Load())
+	}
+}
@@ -0,0 +1,71 @@
+package reonce
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// A Warmup reports the progress of a call to Warm.
+type Warmup struct {
+	total int
+	done  atomic.Int64
+	errs  []error // indexed by position in the list being compiled
+	ch    chan struct{}
+	err   error // set before ch is closed
+}
+
+// Warm compiles, in the background, every registered Regexp (see Walk)
+// that has not been compiled yet using up to concurrency goroutines. If
+// concurrency <= 0, runtime.GOMAXPROCS(0) is used. Warm returns immediately
+// and the returned Warmup can be used to track its progress.
+//
+// Compiling a Regexp with Warm is equivalent to calling its Compile method,
+// so it is safe to use the Regexps while they are being warmed. Warm stops
+// starting new compilations once ctx is canceled.
+func Warm(ctx context.Context, concurrency int) *Warmup {
+	var list []*Regexp
+	for _, re := range registered() {
+		if !re.Compiled() {
+			list = append(list, re)
+		}
+	}
+	w := &Warmup{
+		total: len(list),
+		errs:  make([]error, len(list)),
+		ch:    make(chan struct{}),
+	}
+	go func() {
+		compileParallel(ctx, list, concurrency, func(i int, err error) {
+			w.errs[i] = err
+			w.done.Add(1)
+		})
+		err := errors.Join(w.errs...)
+		if int(w.done.Load()) != w.total {
+			err = errors.Join(ctx.Err(), err)
+		}
+		w.err = err
+		close(w.ch)
+	}()
+	return w
+}
+
+// Progress returns the number of Regexps that have been compiled, including
+// those that failed to compile, and the total number of Regexps that are
+// being warmed.
+func (w *Warmup) Progress() (compiled, total int) {
+	return int(w.done.Load()), w.total
+}
+
+// Done returns a channel that is closed when warming is finished, either
+// because all Regexps were compiled or the context was canceled.
+func (w *Warmup) Done() <-chan struct{} { return w.ch }
+
+// Wait blocks until warming is finished and returns an error joining the
+// compile errors of every Regexp that failed to compile. If the context was
+// canceled before all Regexps were compiled, the context's error is
+// included as well.
+func (w *Warmup) Wait() error {
+	<-w.ch
+	return w.err
+}
@@ -0,0 +1,81 @@
+//go:build !reoncetest
+// +build !reoncetest
+
+package reonce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWarm(t *testing.T) {
+	bad := New("*")
+	done := New("a")
+	done.MustCompile()
+	list := []*Regexp{New("a"), NewPOSIX("b"), bad, done, New(`\w+`)}
+	withRegistry(t, list...)
+
+	// Warm selects the Regexps to compile before returning so start using
+	// them after calling it, while they are compiled, to keep the total
+	// deterministic.
+	w := Warm(context.Background(), 2)
+	var wg sync.WaitGroup
+	for _, re := range list {
+		if re != bad {
+			wg.Add(1)
+			go func(re *Regexp) {
+				defer wg.Done()
+				re.MatchString("abc")
+			}(re)
+		}
+	}
+
+	err := w.Wait()
+	wg.Wait()
+	if !errors.Is(err, bad.Err()) {
+		t.Errorf("Wait: expected error %v got: %v", bad.Err(), err)
+	}
+	select {
+	case <-w.Done():
+	default:
+		t.Error("Done channel not closed after Wait returned")
+	}
+	if n, total := w.Progress(); n != 4 || total != 4 {
+		t.Errorf("Progress: got: %d/%d want: 4/4", n, total)
+	}
+	for _, re := range list {
+		if !re.Compiled() {
+			t.Errorf("%q: not compiled", re.String())
+		}
+	}
+}
+
+func TestWarmEmpty(t *testing.T) {
+	withRegistry(t)
+	w := Warm(context.Background(), 0)
+	select {
+	case <-w.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for Warm")
+	}
+	if err := w.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWarmCanceled(t *testing.T) {
+	list := []*Regexp{New("a"), New("b"), New("c")}
+	withRegistry(t, list...)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := Warm(ctx, 1)
+	if err := w.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait: got: %v want: %v", err, context.Canceled)
+	}
+	if n, total := w.Progress(); n != 0 || total != len(list) {
+		t.Errorf("Progress: got: %d/%d want: 0/%d", n, total, len(list))
+	}
+}